@@ -0,0 +1,130 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultHeader is the default request header carrying the API key.
+	defaultHeader = "X-Api-Key"
+
+	// defaultErrorBody is the default response body when authentication fails.
+	defaultErrorBody = "Unauthorized"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the default key type used to store the authenticated Key within the context.
+type CtxKey struct{}
+
+// Config is the configuration for the APIKey middleware.
+type Config struct {
+	ctxKey           any
+	header           string
+	now              func() time.Time
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the APIKey configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the authenticated Key to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithHeader specifies the request header carrying the API key. The default is "X-Api-Key".
+func WithHeader(name string) Option {
+	return func(c *Config) {
+		c.header = name
+	}
+}
+
+// WithClock sets the function returning the current time, used to check key expiry.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when authentication fails.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// APIKey creates middleware that authenticates requests with an API key.
+//
+// The key is read from the X-Api-Key header (or the header given with WithHeader) and looked up in store.
+// Requests with a missing, unknown or expired key are rejected with 401 Unauthorized.
+// On success the Key, with its owner and metadata, is set in the context under CtxKey{}
+// (or the key given with WithCtxKey). If the store fails, the error is returned to the caller.
+//
+// Example:
+//
+//	store, err := apikey.NewHashedStore(map[string]apikey.Key{
+//	    "9f86d08...": {ID: "acme-2024", Owner: "acme"},
+//	    "60303ae...": {ID: "acme-2025", Owner: "acme"}, // rotated key, both active
+//	})
+//	handler := middleware.Use(myHandler, apikey.APIKey(store))
+//
+//	// In the handler
+//	key := ctx.Value(apikey.CtxKey{}).(apikey.Key)
+func APIKey(store KeyStore, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		header:           defaultHeader,
+		now:              time.Now,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			secret := header.Request(&request).Get(config.header)
+			if secret == "" {
+				return errorResponse, nil
+			}
+
+			key, err := store.Lookup(ctx, secret)
+			if errors.Is(err, ErrKeyNotFound) {
+				return errorResponse, nil
+			}
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, fmt.Errorf("apikey: %w", err)
+			}
+			if !key.ExpiresAt.IsZero() && !config.now().Before(key.ExpiresAt) {
+				return errorResponse, nil
+			}
+
+			ctxWithKey := context.WithValue(ctx, config.ctxKey, key)
+			return next(ctxWithKey, request)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the fixed current time used in tests.
+var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestAPIKey(t *testing.T) {
+	store := NewMemoryStore(map[string]Key{
+		"valid":   {ID: "acme-2", Owner: "acme", Metadata: map[string]string{"plan": "gold"}},
+		"expired": {ID: "acme-1", Owner: "acme", ExpiresAt: now},
+		"expires": {ID: "acme-3", Owner: "acme", ExpiresAt: now.Add(time.Hour)},
+	})
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		multiHeaders   map[string][]string
+		expectedStatus int
+		expectedID     string
+	}{
+		{"Valid key", map[string]string{"X-Api-Key": "valid"}, nil, http.StatusOK, "acme-2"},
+		{"Case-insensitive header", map[string]string{"x-api-key": "valid"}, nil, http.StatusOK, "acme-2"},
+		{"Multi-value header", nil, map[string][]string{"X-API-KEY": {"valid"}}, http.StatusOK, "acme-2"},
+		{"Key not yet expired", map[string]string{"X-Api-Key": "expires"}, nil, http.StatusOK, "acme-3"},
+		{"Expired key", map[string]string{"X-Api-Key": "expired"}, nil, http.StatusUnauthorized, ""},
+		{"Unknown key", map[string]string{"X-Api-Key": "unknown"}, nil, http.StatusUnauthorized, ""},
+		{"Missing key", nil, nil, http.StatusUnauthorized, ""},
+		{"Empty key", map[string]string{"X-Api-Key": ""}, nil, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			var key Key
+			nextCalled := false
+
+			handler := APIKey(store, WithClock(func() time.Time { return now }))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				nextCalled = true
+				key = ctx.Value(CtxKey{}).(Key)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: tt.headers, MultiValueHeaders: tt.multiHeaders})
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatus, response.StatusCode)
+			assert.Equal(tt.expectedStatus == http.StatusOK, nextCalled)
+			assert.Equal(tt.expectedID, key.ID)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestAPIKey_Options(t *testing.T) {
+	assert := assert.New(t)
+	type keyCtxKey struct{}
+	store := NewMemoryStore(map[string]Key{"valid": {ID: "acme-1", Owner: "acme"}})
+
+	handler := APIKey(store,
+		WithCtxKey(keyCtxKey{}),
+		WithHeader("X-Partner-Key"),
+		WithResponse("application/json", `{"error":"invalid api key"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Equal("acme", ctx.Value(keyCtxKey{}).(Key).Owner)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"X-Partner-Key": "valid"}})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// The default header is not read
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"X-Api-Key": "valid"}})
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`{"error":"invalid api key"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestAPIKey_StoreError(t *testing.T) {
+	storeErr := errors.New("table unavailable")
+	store := KeyStoreFunc(func(ctx context.Context, secret string) (Key, error) {
+		return Key{}, storeErr
+	})
+
+	handler := APIKey(store)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		t.Fatal("next must not be called")
+		return events.APIGatewayProxyResponse{}, nil
+	})
+
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"X-Api-Key": "valid"}})
+	assert.ErrorIs(t, err, storeErr)
+}
@@ -0,0 +1,176 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound is returned by a KeyStore when the presented key is unknown.
+var ErrKeyNotFound = errors.New("apikey: key not found")
+
+// Key describes an API key. It never holds the secret itself.
+type Key struct {
+	// ID identifies the key, e.g. in logs. It is not secret.
+	ID string
+	// Owner is the partner or client the key was issued to.
+	Owner string
+	// Metadata holds arbitrary attributes of the key, such as a plan or tenant.
+	Metadata map[string]string
+	// ExpiresAt is the time after which the key is rejected. The zero value means the key does not expire.
+	ExpiresAt time.Time
+}
+
+// KeyStore looks up API keys.
+type KeyStore interface {
+	// Lookup returns the Key for the presented secret, or ErrKeyNotFound if there is none.
+	Lookup(ctx context.Context, secret string) (Key, error)
+}
+
+// KeyStoreFunc is an adapter to allow the use of ordinary functions as KeyStore.
+type KeyStoreFunc func(ctx context.Context, secret string) (Key, error)
+
+// Lookup calls f(ctx, secret).
+func (f KeyStoreFunc) Lookup(ctx context.Context, secret string) (Key, error) {
+	return f(ctx, secret)
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of secret, as expected by HashedStore.
+func HashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// keySet is a set of keys indexed by the SHA-256 hash of their secret.
+// It is safe for concurrent use.
+type keySet struct {
+	mu   sync.RWMutex
+	keys map[[sha256.Size]byte]Key
+}
+
+// add registers key under the given hash.
+func (s *keySet) add(hash [sha256.Size]byte, key Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys == nil {
+		s.keys = make(map[[sha256.Size]byte]Key)
+	}
+	s.keys[hash] = key
+}
+
+// remove unregisters the key with the given hash.
+func (s *keySet) remove(hash [sha256.Size]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, hash)
+}
+
+// lookup returns the key whose hash matches secret.
+// Every registered hash is compared in constant time, so the time taken does not depend on the matching key.
+func (s *keySet) lookup(secret string) (Key, error) {
+	hash := sha256.Sum256([]byte(secret))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found Key
+	match := 0
+	for h, key := range s.keys {
+		if subtle.ConstantTimeCompare(h[:], hash[:]) == 1 {
+			found = key
+			match = 1
+		}
+	}
+	if match == 0 {
+		return Key{}, ErrKeyNotFound
+	}
+	return found, nil
+}
+
+// MemoryStore is a KeyStore holding plaintext keys in memory, e.g. loaded from a secret at startup.
+// Several keys may be registered for the same owner, so keys can be rotated without downtime.
+type MemoryStore struct {
+	set keySet
+}
+
+// NewMemoryStore returns a MemoryStore holding the given keys, indexed by secret.
+func NewMemoryStore(keys map[string]Key) *MemoryStore {
+	s := &MemoryStore{}
+	for secret, key := range keys {
+		s.Add(secret, key)
+	}
+	return s
+}
+
+// Add registers key under secret.
+func (s *MemoryStore) Add(secret string, key Key) {
+	s.set.add(sha256.Sum256([]byte(secret)), key)
+}
+
+// Remove revokes the key registered under secret.
+func (s *MemoryStore) Remove(secret string) {
+	s.set.remove(sha256.Sum256([]byte(secret)))
+}
+
+// Lookup implements KeyStore.
+func (s *MemoryStore) Lookup(ctx context.Context, secret string) (Key, error) {
+	return s.set.lookup(secret)
+}
+
+// HashedStore is a KeyStore holding only the SHA-256 hashes of the keys (see HashKey),
+// so that the configuration does not contain usable secrets.
+// Several keys may be registered for the same owner, so keys can be rotated without downtime.
+type HashedStore struct {
+	set keySet
+}
+
+// NewHashedStore returns a HashedStore holding the given keys, indexed by hex-encoded SHA-256 hash.
+func NewHashedStore(keys map[string]Key) (*HashedStore, error) {
+	s := &HashedStore{}
+	for hash, key := range keys {
+		if err := s.Add(hash, key); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add registers key under the hex-encoded SHA-256 hash of its secret.
+func (s *HashedStore) Add(hash string, key Key) error {
+	h, err := decodeHash(hash)
+	if err != nil {
+		return err
+	}
+	s.set.add(h, key)
+	return nil
+}
+
+// Remove revokes the key registered under the hex-encoded SHA-256 hash of its secret.
+func (s *HashedStore) Remove(hash string) error {
+	h, err := decodeHash(hash)
+	if err != nil {
+		return err
+	}
+	s.set.remove(h)
+	return nil
+}
+
+// Lookup implements KeyStore.
+func (s *HashedStore) Lookup(ctx context.Context, secret string) (Key, error) {
+	return s.set.lookup(secret)
+}
+
+// decodeHash decodes a hex-encoded SHA-256 hash.
+func decodeHash(hash string) ([sha256.Size]byte, error) {
+	var h [sha256.Size]byte
+	b, err := hex.DecodeString(hash)
+	if err != nil || len(b) != sha256.Size {
+		return h, fmt.Errorf("apikey: invalid SHA-256 hash %q", hash)
+	}
+	copy(h[:], b)
+	return h, nil
+}
@@ -0,0 +1,65 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryStore(map[string]Key{
+		"old-secret": {ID: "acme-1", Owner: "acme"},
+		"new-secret": {ID: "acme-2", Owner: "acme"},
+	})
+
+	// Both keys of the owner are active during rotation
+	key, err := store.Lookup(context.Background(), "old-secret")
+	assert.NoError(err)
+	assert.Equal("acme-1", key.ID)
+	key, err = store.Lookup(context.Background(), "new-secret")
+	assert.NoError(err)
+	assert.Equal("acme-2", key.ID)
+
+	// Revoking the old key
+	store.Remove("old-secret")
+	_, err = store.Lookup(context.Background(), "old-secret")
+	assert.ErrorIs(err, ErrKeyNotFound)
+
+	store.Add("other-secret", Key{ID: "globex-1", Owner: "globex"})
+	key, err = store.Lookup(context.Background(), "other-secret")
+	assert.NoError(err)
+	assert.Equal("globex", key.Owner)
+
+	_, err = store.Lookup(context.Background(), "")
+	assert.ErrorIs(err, ErrKeyNotFound)
+}
+
+func TestHashedStore(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", HashKey("secret"))
+
+	store, err := NewHashedStore(map[string]Key{
+		HashKey("secret"): {ID: "acme-1", Owner: "acme", Metadata: map[string]string{"plan": "gold"}},
+	})
+	assert.NoError(err)
+
+	key, err := store.Lookup(context.Background(), "secret")
+	assert.NoError(err)
+	assert.Equal("gold", key.Metadata["plan"])
+
+	// The hash itself is not a valid key
+	_, err = store.Lookup(context.Background(), HashKey("secret"))
+	assert.ErrorIs(err, ErrKeyNotFound)
+
+	assert.NoError(store.Remove(HashKey("secret")))
+	_, err = store.Lookup(context.Background(), "secret")
+	assert.ErrorIs(err, ErrKeyNotFound)
+
+	// Invalid hashes are rejected
+	assert.Error(store.Add("not-hex", Key{}))
+	assert.Error(store.Add("abcd", Key{}))
+	_, err = NewHashedStore(map[string]Key{"xyz": {}})
+	assert.Error(err)
+}
@@ -0,0 +1,132 @@
+package basic
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultRealm is the default protection space sent in the WWW-Authenticate challenge.
+	defaultRealm = "Restricted"
+
+	// defaultErrorBody is the default response body when authentication fails.
+	defaultErrorBody = "Unauthorized"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the default key type used to store the authenticated username within the context.
+type CtxKey struct{}
+
+// Config is the configuration for the Basic middleware.
+type Config struct {
+	ctxKey           any
+	realm            string
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the Basic configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the authenticated username to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithRealm sets the realm sent in the WWW-Authenticate challenge. The default is "Restricted".
+func WithRealm(realm string) Option {
+	return func(c *Config) {
+		c.realm = realm
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when authentication fails.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// Basic creates middleware that authenticates requests with HTTP Basic authentication (RFC 7617).
+//
+// The credentials of the "Authorization: Basic" header are checked with verifier. On success the username
+// is set in the context under CtxKey{} (or the key given with WithCtxKey). Otherwise, the middleware returns
+// 401 Unauthorized with a WWW-Authenticate challenge. If verifier fails, the error is returned to the caller.
+//
+// Example:
+//
+//	verifier := basic.BcryptVerifier(map[string]string{
+//	    "admin": "$2a$10$...",
+//	})
+//	handler := middleware.Use(myHandler, basic.Basic(verifier, basic.WithRealm("admin")))
+//
+//	// In the handler
+//	username := ctx.Value(basic.CtxKey{}).(string)
+func Basic(verifier Verifier, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		realm:            defaultRealm,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.errorBody,
+		Headers: map[string]string{
+			"Content-Type":     config.errorContentType,
+			"WWW-Authenticate": fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, config.realm),
+		},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			username, password, ok := parseCredentials(header.Request(&request).Get("Authorization"))
+			if !ok {
+				return errorResponse, nil
+			}
+
+			valid, err := verifier.Verify(ctx, username, password)
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, fmt.Errorf("basic: %w", err)
+			}
+			if !valid {
+				return errorResponse, nil
+			}
+
+			ctxWithUsername := context.WithValue(ctx, config.ctxKey, username)
+			return next(ctxWithUsername, request)
+		}
+	}
+}
+
+// parseCredentials parses the value of a Basic Authorization header.
+func parseCredentials(authorization string) (username, password string, ok bool) {
+	scheme, encoded, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Basic") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
@@ -0,0 +1,101 @@
+package basic
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// basicAuth returns the Authorization header value for the given credentials.
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+func TestBasic(t *testing.T) {
+	verifier := StaticVerifier(map[string]string{"admin": "pa:ss"})
+
+	tests := []struct {
+		name             string
+		headers          map[string]string
+		expectedStatus   int
+		expectedUsername string
+	}{
+		{"Valid credentials", map[string]string{"Authorization": basicAuth("admin", "pa:ss")}, http.StatusOK, "admin"},
+		{"Lowercase scheme and header", map[string]string{"authorization": "basic " + base64.StdEncoding.EncodeToString([]byte("admin:pa:ss"))}, http.StatusOK, "admin"},
+		{"Wrong password", map[string]string{"Authorization": basicAuth("admin", "wrong")}, http.StatusUnauthorized, ""},
+		{"Unknown user", map[string]string{"Authorization": basicAuth("guest", "pa:ss")}, http.StatusUnauthorized, ""},
+		{"Missing header", nil, http.StatusUnauthorized, ""},
+		{"Bearer scheme", map[string]string{"Authorization": "Bearer token"}, http.StatusUnauthorized, ""},
+		{"Invalid base64", map[string]string{"Authorization": "Basic !!!"}, http.StatusUnauthorized, ""},
+		{"Missing colon", map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("admin"))}, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			var username string
+
+			handler := Basic(verifier)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				username = ctx.Value(CtxKey{}).(string)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: tt.headers})
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatus, response.StatusCode)
+			assert.Equal(tt.expectedUsername, username)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(`Basic realm="Restricted", charset="UTF-8"`, response.Headers["WWW-Authenticate"])
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestBasic_Options(t *testing.T) {
+	assert := assert.New(t)
+	type userCtxKey struct{}
+
+	handler := Basic(StaticVerifier(map[string]string{"admin": "secret"}),
+		WithCtxKey(userCtxKey{}),
+		WithRealm("admin area"),
+		WithResponse("application/json", `{"error":"unauthorized"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Equal("admin", ctx.Value(userCtxKey{}))
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"Authorization": {basicAuth("admin", "secret")}},
+	})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`Basic realm="admin area", charset="UTF-8"`, response.Headers["WWW-Authenticate"])
+	assert.Equal(`{"error":"unauthorized"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestBasic_VerifierError(t *testing.T) {
+	verifierErr := errors.New("directory unavailable")
+	verifier := VerifierFunc(func(ctx context.Context, username, password string) (bool, error) {
+		return false, verifierErr
+	})
+
+	handler := Basic(verifier)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		t.Fatal("next must not be called")
+		return events.APIGatewayProxyResponse{}, nil
+	})
+
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"Authorization": basicAuth("admin", "secret")}})
+	assert.ErrorIs(t, err, verifierErr)
+}
@@ -0,0 +1,65 @@
+package basic
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Verifier validates the credentials of a request.
+type Verifier interface {
+	// Verify reports whether password is valid for username.
+	// A non-nil error means the credentials could not be checked, not that they are invalid.
+	Verify(ctx context.Context, username, password string) (bool, error)
+}
+
+// VerifierFunc is an adapter to allow the use of ordinary functions as Verifier.
+type VerifierFunc func(ctx context.Context, username, password string) (bool, error)
+
+// Verify calls f(ctx, username, password).
+func (f VerifierFunc) Verify(ctx context.Context, username, password string) (bool, error) {
+	return f(ctx, username, password)
+}
+
+// StaticVerifier returns a Verifier checking credentials against a map of usernames to plaintext passwords.
+// Passwords are compared in constant time.
+func StaticVerifier(users map[string]string) Verifier {
+	return VerifierFunc(func(ctx context.Context, username, password string) (bool, error) {
+		expected, ok := users[username]
+		// Compare fixed-size hashes so that the time taken does not depend on the password lengths
+		given := sha256.Sum256([]byte(password))
+		want := sha256.Sum256([]byte(expected))
+		match := subtle.ConstantTimeCompare(given[:], want[:]) == 1
+		return ok && match, nil
+	})
+}
+
+// BcryptVerifier returns a Verifier checking credentials against a map of usernames to bcrypt password hashes.
+// Unknown usernames are checked against a dummy hash, so that they take as long as known ones.
+func BcryptVerifier(hashes map[string]string) Verifier {
+	return VerifierFunc(func(ctx context.Context, username, password string) (bool, error) {
+		hash, ok := hashes[username]
+		if !ok {
+			_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+			return false, nil
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// dummyHash returns a bcrypt hash used to check passwords of unknown users.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
@@ -0,0 +1,59 @@
+package basic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestStaticVerifier(t *testing.T) {
+	verifier := StaticVerifier(map[string]string{"admin": "s3cret", "empty": ""})
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		expected bool
+	}{
+		{"Valid credentials", "admin", "s3cret", true},
+		{"Wrong password", "admin", "wrong", false},
+		{"Password prefix", "admin", "s3c", false},
+		{"Unknown user", "guest", "s3cret", false},
+		{"Unknown user with empty password", "guest", "", false},
+		{"Empty password", "empty", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := verifier.Verify(context.Background(), tt.username, tt.password)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, valid)
+		})
+	}
+}
+
+func TestBcryptVerifier(t *testing.T) {
+	assert := assert.New(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	assert.NoError(err)
+	verifier := BcryptVerifier(map[string]string{"admin": string(hash), "broken": "not a hash"})
+
+	valid, err := verifier.Verify(context.Background(), "admin", "s3cret")
+	assert.NoError(err)
+	assert.True(valid)
+
+	valid, err = verifier.Verify(context.Background(), "admin", "wrong")
+	assert.NoError(err)
+	assert.False(valid)
+
+	valid, err = verifier.Verify(context.Background(), "guest", "s3cret")
+	assert.NoError(err)
+	assert.False(valid)
+
+	// Malformed hashes are reported as errors
+	valid, err = verifier.Verify(context.Background(), "broken", "s3cret")
+	assert.Error(err)
+	assert.False(valid)
+}
@@ -0,0 +1,90 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+// NumericDate is a JWT NumericDate value: the number of seconds since the Unix epoch.
+type NumericDate struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Fractional seconds are accepted.
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return errors.New("jwt: NumericDate must be a number")
+	}
+	whole, frac := math.Modf(seconds)
+	d.Time = time.Unix(int64(whole), int64(frac*1e9)).UTC()
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Unix())
+}
+
+// Audience is the "aud" claim, which may be a single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return errors.New("jwt: aud must be a string or an array of strings")
+	}
+	*a = multiple
+	return nil
+}
+
+// Claims holds the claims of a verified token.
+// Registered claims are available as fields, and all claims, including private ones, in Raw.
+type Claims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+
+	// Raw holds every claim of the token as decoded by encoding/json.
+	Raw map[string]any `json:"-"`
+
+	payload []byte
+}
+
+// Decode unmarshals the token payload into v, which allows private claims to be read into a typed struct.
+//
+// Example:
+//
+//	var custom struct {
+//	    Email string   `json:"email"`
+//	    Roles []string `json:"roles"`
+//	}
+//	err := claims.Decode(&custom)
+func (c Claims) Decode(v any) error {
+	return json.Unmarshal(c.payload, v)
+}
+
+// parseClaims decodes a token payload into Claims.
+func parseClaims(payload []byte) (Claims, error) {
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, err
+	}
+	if err := json.Unmarshal(payload, &claims.Raw); err != nil {
+		return Claims{}, err
+	}
+	claims.payload = payload
+	return claims, nil
+}
@@ -0,0 +1,241 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/cookie"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultErrorBody is the default response body when authentication fails.
+	defaultErrorBody = "Unauthorized"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+var (
+	// errExpired is returned for tokens whose "exp" claim is in the past.
+	errExpired = errors.New("jwt: token is expired")
+
+	// errNotYetValid is returned for tokens whose "nbf" claim is in the future.
+	errNotYetValid = errors.New("jwt: token is not valid yet")
+
+	// errIssuer is returned for tokens issued by an unexpected issuer.
+	errIssuer = errors.New("jwt: unexpected issuer")
+
+	// errAudience is returned for tokens not intended for the expected audience.
+	errAudience = errors.New("jwt: unexpected audience")
+)
+
+// CtxKey is the default key type used to store the verified Claims within the context.
+type CtxKey struct{}
+
+// Config is the configuration for the JWT middleware.
+type Config struct {
+	ctxKey           any
+	algorithms       []string
+	issuers          []string
+	audiences        []string
+	leeway           time.Duration
+	cookieName       string
+	now              func() time.Time
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the JWT configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the verified Claims to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithAlgorithms restricts the accepted signature algorithms.
+// By default, HS256, RS256 and ES256 are accepted.
+func WithAlgorithms(algorithms ...string) Option {
+	return func(c *Config) {
+		c.algorithms = algorithms
+	}
+}
+
+// WithIssuer requires the "iss" claim to be one of the given issuers.
+func WithIssuer(issuers ...string) Option {
+	return func(c *Config) {
+		c.issuers = issuers
+	}
+}
+
+// WithAudience requires the "aud" claim to contain at least one of the given audiences.
+func WithAudience(audiences ...string) Option {
+	return func(c *Config) {
+		c.audiences = audiences
+	}
+}
+
+// WithLeeway sets the allowed clock skew when checking the "exp" and "nbf" claims.
+// By default, no leeway is allowed.
+func WithLeeway(leeway time.Duration) Option {
+	return func(c *Config) {
+		c.leeway = leeway
+	}
+}
+
+// WithCookie specifies a cookie from which the token is read when the request has no Authorization header.
+func WithCookie(name string) Option {
+	return func(c *Config) {
+		c.cookieName = name
+	}
+}
+
+// WithClock sets the function returning the current time.
+// By default, time.Now is used.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when authentication fails.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// JWT creates middleware that authenticates requests with a JSON Web Token.
+//
+// The token is read from the "Authorization: Bearer <token>" header, or from a cookie specified with WithCookie.
+// Its signature is verified with the key returned by keys for the token's "kid" header and algorithm
+// (HS256, RS256 or ES256). Then the "exp" and "nbf" claims are checked, allowing for the configured leeway,
+// and the "iss" and "aud" claims if WithIssuer or WithAudience are specified.
+//
+// On success the verified Claims are set in the context under CtxKey{} (or the key given with WithCtxKey).
+// Otherwise, the middleware returns 401 Unauthorized with a WWW-Authenticate header.
+//
+// Example:
+//
+//	keys := jwt.NewJWKSProvider(jwt.FileSource("jwks.json"))
+//	handler := middleware.Use(myHandler, jwt.JWT(keys, jwt.WithIssuer("https://issuer.example.com"), jwt.WithAudience("my-api")))
+//
+//	// In the handler
+//	claims := ctx.Value(jwt.CtxKey{}).(jwt.Claims)
+func JWT(keys KeyProvider, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		algorithms:       []string{HS256, RS256, ES256},
+		now:              time.Now,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			raw := extractToken(&request, config.cookieName)
+			if raw == "" {
+				return errorResponse(&config, "Bearer"), nil
+			}
+
+			claims, err := verify(ctx, &config, keys, raw)
+			if err != nil {
+				return errorResponse(&config, `Bearer error="invalid_token"`), nil
+			}
+
+			ctxWithClaims := context.WithValue(ctx, config.ctxKey, claims)
+			return next(ctxWithClaims, request)
+		}
+	}
+}
+
+// verify parses raw, verifies its signature and validates its claims.
+func verify(ctx context.Context, config *Config, keys KeyProvider, raw string) (Claims, error) {
+	tok, err := parseToken(raw)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	// Only explicitly allowed algorithms are accepted, which in particular rejects "none"
+	if !slices.Contains(config.algorithms, tok.header.Algorithm) {
+		return Claims{}, errors.New("jwt: algorithm not allowed")
+	}
+
+	key, err := keys.Key(ctx, tok.header.KeyID, tok.header.Algorithm)
+	if err != nil {
+		return Claims{}, err
+	}
+	if err := verifySignature(tok.header.Algorithm, key, tok.signingInput, tok.signature); err != nil {
+		return Claims{}, err
+	}
+
+	claims, err := parseClaims(tok.payload)
+	if err != nil {
+		return Claims{}, errMalformed
+	}
+
+	now := config.now()
+	if claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Add(config.leeway)) {
+		return Claims{}, errExpired
+	}
+	if claims.NotBefore != nil && now.Add(config.leeway).Before(claims.NotBefore.Time) {
+		return Claims{}, errNotYetValid
+	}
+	if len(config.issuers) > 0 && !slices.Contains(config.issuers, claims.Issuer) {
+		return Claims{}, errIssuer
+	}
+	if len(config.audiences) > 0 && !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(config.audiences, aud)
+	}) {
+		return Claims{}, errAudience
+	}
+
+	return claims, nil
+}
+
+// extractToken returns the bearer token of the request, or the value of the named cookie if there is none.
+func extractToken(request *events.APIGatewayProxyRequest, cookieName string) string {
+	if authorization := header.Request(request).Get("Authorization"); authorization != "" {
+		scheme, token, ok := strings.Cut(authorization, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	if cookieName == "" {
+		return ""
+	}
+	c, err := cookie.Get(request, cookieName)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// errorResponse builds the 401 response with the given WWW-Authenticate challenge.
+func errorResponse(config *Config, challenge string) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.errorBody,
+		Headers: map[string]string{
+			"Content-Type":     config.errorContentType,
+			"WWW-Authenticate": challenge,
+		},
+	}
+}
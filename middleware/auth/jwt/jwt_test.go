@@ -0,0 +1,361 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the current time used by the tests.
+var now = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// Keys used to sign test tokens.
+var (
+	hmacSecret = []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _  = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _   = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+// sign creates a compact JWS token with the given header and claims.
+func sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	h := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		h["kid"] = kid
+	}
+	headerJSON, _ := json.Marshal(h)
+	claimsJSON, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case HS256:
+		mac := hmac.New(sha256.New, hmacSecret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case RS256:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case ES256:
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case "none":
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// validClaims returns claims valid at now.
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss":   "https://issuer.example.com",
+		"sub":   "user-1",
+		"aud":   "my-api",
+		"exp":   now.Add(time.Hour).Unix(),
+		"nbf":   now.Add(-time.Minute).Unix(),
+		"iat":   now.Add(-time.Minute).Unix(),
+		"email": "user@example.com",
+		"roles": []string{"admin"},
+	}
+}
+
+// withClaim returns validClaims with one claim replaced (or removed if value is nil).
+func withClaim(name string, value any) map[string]any {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+	return claims
+}
+
+// createRequest creates a request with the given headers.
+func createRequest(headers map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{HTTPMethod: "GET", Headers: headers}
+}
+
+// bearer returns headers carrying token as a bearer token.
+func bearer(token string) map[string]string {
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+func TestJWT(t *testing.T) {
+	keys := StaticKeys(map[string]any{
+		"hmac": hmacSecret,
+		"rsa":  &rsaKey.PublicKey,
+		"ec":   &ecKey.PublicKey,
+	})
+
+	tests := []struct {
+		name              string
+		headers           map[string]string
+		expectedStatus    int
+		expectedChallenge string
+	}{
+		{
+			name:           "HS256",
+			headers:        bearer(sign(t, HS256, "hmac", validClaims())),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RS256",
+			headers:        bearer(sign(t, RS256, "rsa", validClaims())),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ES256",
+			headers:        bearer(sign(t, ES256, "ec", validClaims())),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Lowercase header and scheme",
+			headers:        map[string]string{"authorization": "bearer " + sign(t, HS256, "hmac", validClaims())},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:              "Missing token",
+			headers:           nil,
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: "Bearer",
+		},
+		{
+			name:              "Other scheme",
+			headers:           map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: "Bearer",
+		},
+		{
+			name:              "Malformed token",
+			headers:           bearer("not-a-token"),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Wrong key",
+			headers:           bearer(sign(t, RS256, "ec", validClaims())),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Unknown key ID",
+			headers:           bearer(sign(t, HS256, "unknown", validClaims())),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Algorithm none",
+			headers:           bearer(sign(t, "none", "hmac", validClaims())),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Tampered token",
+			headers:           bearer(sign(t, HS256, "hmac", validClaims())[:40] + "x" + sign(t, HS256, "hmac", validClaims())[41:]),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Expired",
+			headers:           bearer(sign(t, HS256, "hmac", withClaim("exp", now.Add(-time.Second).Unix()))),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:              "Not yet valid",
+			headers:           bearer(sign(t, HS256, "hmac", withClaim("nbf", now.Add(time.Minute).Unix()))),
+			expectedStatus:    http.StatusUnauthorized,
+			expectedChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "Without exp",
+			headers:        bearer(sign(t, HS256, "hmac", withClaim("exp", nil))),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			nextCalled := false
+
+			handler := JWT(keys, WithClock(func() time.Time { return now }))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				nextCalled = true
+				claims, ok := ctx.Value(CtxKey{}).(Claims)
+				assert.True(ok)
+				assert.Equal("user-1", claims.Subject)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), createRequest(tt.headers))
+
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatus, response.StatusCode)
+			assert.Equal(tt.expectedStatus == http.StatusOK, nextCalled)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.Equal(tt.expectedChallenge, response.Headers["WWW-Authenticate"])
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestJWT_ClaimChecks(t *testing.T) {
+	keys := StaticKey(hmacSecret)
+	clock := WithClock(func() time.Time { return now })
+
+	tests := []struct {
+		name           string
+		opts           []Option
+		claims         map[string]any
+		expectedStatus int
+	}{
+		{
+			name:           "Issuer matches",
+			opts:           []Option{WithIssuer("https://other.example.com", "https://issuer.example.com")},
+			claims:         validClaims(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Issuer mismatch",
+			opts:           []Option{WithIssuer("https://other.example.com")},
+			claims:         validClaims(),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Audience array matches",
+			opts:           []Option{WithAudience("my-api")},
+			claims:         withClaim("aud", []string{"other-api", "my-api"}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Audience mismatch",
+			opts:           []Option{WithAudience("my-api")},
+			claims:         withClaim("aud", "other-api"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Audience missing",
+			opts:           []Option{WithAudience("my-api")},
+			claims:         withClaim("aud", nil),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Expired within leeway",
+			opts:           []Option{WithLeeway(time.Minute)},
+			claims:         withClaim("exp", now.Add(-30*time.Second).Unix()),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Not yet valid within leeway",
+			opts:           []Option{WithLeeway(time.Minute)},
+			claims:         withClaim("nbf", now.Add(30*time.Second).Unix()),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Algorithm not allowed",
+			opts:           []Option{WithAlgorithms(RS256)},
+			claims:         validClaims(),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Invalid claim type",
+			opts:           nil,
+			claims:         withClaim("exp", "tomorrow"),
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := JWT(keys, append(tt.opts, clock)...)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), createRequest(bearer(sign(t, HS256, "", tt.claims))))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, response.StatusCode)
+		})
+	}
+}
+
+func TestJWT_Cookie(t *testing.T) {
+	assert := assert.New(t)
+	token := sign(t, HS256, "", validClaims())
+
+	handler := JWT(StaticKey(hmacSecret), WithCookie("session"), WithClock(func() time.Time { return now }))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), createRequest(map[string]string{"Cookie": "theme=dark; session=" + token}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"cookie": {"theme=dark", "session=" + token}},
+	})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, err = handler(context.Background(), createRequest(map[string]string{"Cookie": "theme=dark"}))
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestJWT_Options(t *testing.T) {
+	assert := assert.New(t)
+	type claimsKey struct{}
+
+	handler := JWT(
+		StaticKey(hmacSecret),
+		WithCtxKey(claimsKey{}),
+		WithClock(func() time.Time { return now }),
+		WithResponse("application/json", `{"error":"unauthorized"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		claims := ctx.Value(claimsKey{}).(Claims)
+		assert.Equal("https://issuer.example.com", claims.Issuer)
+		assert.Equal(Audience{"my-api"}, claims.Audience)
+		assert.Equal(now.Add(time.Hour), claims.ExpiresAt.Time)
+		assert.Equal("user@example.com", claims.Raw["email"])
+
+		var custom struct {
+			Email string   `json:"email"`
+			Roles []string `json:"roles"`
+		}
+		assert.NoError(claims.Decode(&custom))
+		assert.Equal([]string{"admin"}, custom.Roles)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), createRequest(bearer(sign(t, HS256, "", validClaims()))))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	response, err = handler(context.Background(), createRequest(nil))
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`{"error":"unauthorized"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
@@ -0,0 +1,341 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheTTL is the default time a fetched JWKS document is used before it is fetched again.
+	defaultCacheTTL = time.Hour
+
+	// defaultMinRefreshInterval is the default minimum time between two fetches triggered by unknown key IDs.
+	defaultMinRefreshInterval = time.Minute
+)
+
+var (
+	// errKeyNotFound is returned when no key matches the key ID and algorithm of a token.
+	errKeyNotFound = errors.New("jwt: key not found")
+
+	// errEmptyKey is returned when a symmetric key is empty.
+	errEmptyKey = errors.New("jwt: empty symmetric key")
+)
+
+// KeyProvider returns the key used to verify a token.
+// Keys are []byte for HS256, *rsa.PublicKey for RS256 and *ecdsa.PublicKey for ES256.
+type KeyProvider interface {
+	// Key returns the verification key for the key ID ("kid" header, possibly empty) and algorithm of a token.
+	Key(ctx context.Context, keyID, algorithm string) (any, error)
+}
+
+// KeyProviderFunc is an adapter to allow the use of ordinary functions as a KeyProvider.
+type KeyProviderFunc func(ctx context.Context, keyID, algorithm string) (any, error)
+
+// Key calls f(ctx, keyID, algorithm).
+func (f KeyProviderFunc) Key(ctx context.Context, keyID, algorithm string) (any, error) {
+	return f(ctx, keyID, algorithm)
+}
+
+// StaticKey returns a KeyProvider that uses key for every token, regardless of its key ID.
+// An empty []byte key never verifies a token.
+func StaticKey(key any) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context, keyID, algorithm string) (any, error) {
+		return key, nil
+	})
+}
+
+// StaticKeys returns a KeyProvider that looks up keys by key ID.
+// Tokens without a key ID, or with an unknown one, are rejected.
+func StaticKeys(keys map[string]any) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context, keyID, algorithm string) (any, error) {
+		key, ok := keys[keyID]
+		if !ok {
+			return nil, errKeyNotFound
+		}
+		return key, nil
+	})
+}
+
+// JWK is a single JSON Web Key (RFC 7517). Only the members needed for verification are decoded.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	K         string `json:"k,omitempty"`
+}
+
+// PublicKey converts the JWK to a verification key: *rsa.PublicKey for "RSA", *ecdsa.PublicKey
+// for "EC" (P-256 only) and []byte for "oct".
+func (k JWK) PublicKey() (any, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("jwt: RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if k.Curve != "P-256" {
+			return nil, fmt.Errorf("jwt: unsupported curve %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("jwt: EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, errors.New("jwt: invalid symmetric key")
+		}
+		if len(secret) == 0 {
+			return nil, errEmptyKey
+		}
+		return secret, nil
+
+	default:
+		return nil, fmt.Errorf("jwt: unsupported key type %q", k.KeyType)
+	}
+}
+
+// decodeBigInt decodes a base64url encoded unsigned big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("jwt: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// jwkSet is a parsed JWKS document.
+type jwkSet struct {
+	keys []jwkEntry
+}
+
+// jwkEntry is a key of a jwkSet with its converted verification key.
+type jwkEntry struct {
+	jwk JWK
+	key any
+}
+
+// ParseJWKS parses a JWKS document (RFC 7517, Section 5) into a KeyProvider.
+// Keys with an unsupported type or curve, or meant for encryption ("use": "enc"), are ignored.
+func ParseJWKS(data []byte) (KeyProvider, error) {
+	set, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return KeyProviderFunc(func(ctx context.Context, keyID, algorithm string) (any, error) {
+		return set.lookup(keyID, algorithm)
+	}), nil
+}
+
+// parseJWKS parses a JWKS document.
+func parseJWKS(data []byte) (*jwkSet, error) {
+	var doc struct {
+		Keys []JWK `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("jwt: invalid JWKS document: %w", err)
+	}
+
+	set := &jwkSet{}
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		set.keys = append(set.keys, jwkEntry{jwk: jwk, key: key})
+	}
+	return set, nil
+}
+
+// lookup returns the key matching keyID that can be used with algorithm.
+// If keyID is empty, the first key usable with algorithm is returned.
+func (s *jwkSet) lookup(keyID, algorithm string) (any, error) {
+	for _, entry := range s.keys {
+		if keyID != "" && entry.jwk.KeyID != keyID {
+			continue
+		}
+		if entry.jwk.Algorithm != "" && entry.jwk.Algorithm != algorithm {
+			continue
+		}
+		if !keyTypeMatches(entry.jwk.KeyType, algorithm) {
+			continue
+		}
+		return entry.key, nil
+	}
+	return nil, errKeyNotFound
+}
+
+// keyTypeMatches reports whether a key of type kty can be used with algorithm.
+func keyTypeMatches(kty, algorithm string) bool {
+	switch algorithm {
+	case HS256:
+		return kty == "oct"
+	case RS256:
+		return kty == "RSA"
+	case ES256:
+		return kty == "EC"
+	}
+	return false
+}
+
+// JWKSSource fetches a JWKS document.
+type JWKSSource interface {
+	FetchJWKS(ctx context.Context) ([]byte, error)
+}
+
+// JWKSSourceFunc is an adapter to allow the use of ordinary functions as a JWKSSource.
+type JWKSSourceFunc func(ctx context.Context) ([]byte, error)
+
+// FetchJWKS calls f(ctx).
+func (f JWKSSourceFunc) FetchJWKS(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// FileSource returns a JWKSSource that reads the JWKS document from the file at path,
+// for example one bundled with the function deployment package.
+func FileSource(path string) JWKSSource {
+	return JWKSSourceFunc(func(ctx context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// JWKSProvider is a KeyProvider backed by a JWKSSource.
+// The fetched document is cached, refreshed once the cache TTL has elapsed, and also refreshed
+// (at most once per minimum refresh interval) when a token refers to an unknown key ID,
+// so that rotated keys are picked up without waiting for the TTL.
+// Intervals are measured from the last fetch attempt, successful or not, so that a failing source
+// is not fetched on every lookup.
+type JWKSProvider struct {
+	source             JWKSSource
+	cacheTTL           time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu          sync.Mutex
+	set         *jwkSet
+	fetchErr    error
+	attemptedAt time.Time
+}
+
+// JWKSOption is a function type to modify the JWKSProvider configuration.
+type JWKSOption func(*JWKSProvider)
+
+// WithCacheTTL sets how long a fetched JWKS document is used before it is fetched again.
+// The default is one hour.
+func WithCacheTTL(ttl time.Duration) JWKSOption {
+	return func(p *JWKSProvider) {
+		p.cacheTTL = ttl
+	}
+}
+
+// WithMinRefreshInterval sets the minimum time between two fetches triggered by unknown key IDs.
+// The default is one minute.
+func WithMinRefreshInterval(interval time.Duration) JWKSOption {
+	return func(p *JWKSProvider) {
+		p.minRefreshInterval = interval
+	}
+}
+
+// NewJWKSProvider creates a JWKSProvider fetching documents from source.
+// The document is fetched lazily on the first lookup.
+func NewJWKSProvider(source JWKSSource, opts ...JWKSOption) *JWKSProvider {
+	p := &JWKSProvider{
+		source:             source,
+		cacheTTL:           defaultCacheTTL,
+		minRefreshInterval: defaultMinRefreshInterval,
+		now:                time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Key implements KeyProvider.
+func (p *JWKSProvider) Key(ctx context.Context, keyID, algorithm string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	interval := p.cacheTTL
+	if p.set == nil {
+		// Without any key, retry a failed fetch as soon as an unknown key ID would trigger one
+		interval = p.minRefreshInterval
+	}
+	if p.attemptedAt.IsZero() || now.Sub(p.attemptedAt) >= interval {
+		// On failure the stale keys are kept, so that an unavailable source does not reject every token
+		_ = p.refresh(ctx, now)
+	}
+	if p.set == nil {
+		return nil, p.fetchErr
+	}
+
+	key, err := p.set.lookup(keyID, algorithm)
+	if errors.Is(err, errKeyNotFound) && now.Sub(p.attemptedAt) >= p.minRefreshInterval {
+		// The key may have been rotated in since the last fetch
+		if err := p.refresh(ctx, now); err != nil {
+			return nil, err
+		}
+		key, err = p.set.lookup(keyID, algorithm)
+	}
+	return key, err
+}
+
+// refresh fetches and parses the JWKS document, recording the attempt and its error. p.mu must be held.
+func (p *JWKSProvider) refresh(ctx context.Context, now time.Time) error {
+	p.attemptedAt = now
+	p.fetchErr = p.fetch(ctx)
+	return p.fetchErr
+}
+
+// fetch fetches and parses the JWKS document. p.mu must be held.
+func (p *JWKSProvider) fetch(ctx context.Context) error {
+	data, err := p.source.FetchJWKS(ctx)
+	if err != nil {
+		return fmt.Errorf("jwt: failed to fetch JWKS: %w", err)
+	}
+	set, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	p.set = set
+	return nil
+}
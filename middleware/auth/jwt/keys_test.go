@@ -0,0 +1,242 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rsaJWK returns the JWK of the test RSA key.
+func rsaJWK(kid string) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}
+}
+
+// ecJWK returns the JWK of the test EC key.
+func ecJWK(kid string) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// jwks builds a JWKS document from the given keys.
+func jwks(keys ...map[string]string) []byte {
+	data, _ := json.Marshal(map[string]any{"keys": keys})
+	return data
+}
+
+func TestParseJWKS(t *testing.T) {
+	assert := assert.New(t)
+	octJWK := map[string]string{"kty": "oct", "kid": "hmac", "alg": "HS256", "k": base64.RawURLEncoding.EncodeToString(hmacSecret)}
+	encJWK := rsaJWK("enc")
+	encJWK["use"] = "enc"
+	unsupported := map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "AAAA"}
+
+	keys, err := ParseJWKS(jwks(rsaJWK("rsa"), ecJWK("ec"), octJWK, encJWK, unsupported))
+	assert.NoError(err)
+
+	key, err := keys.Key(context.Background(), "rsa", RS256)
+	assert.NoError(err)
+	assert.True(rsaKey.PublicKey.Equal(key.(*rsa.PublicKey)))
+
+	key, err = keys.Key(context.Background(), "ec", ES256)
+	assert.NoError(err)
+	assert.True(ecKey.PublicKey.Equal(key.(*ecdsa.PublicKey)))
+
+	key, err = keys.Key(context.Background(), "hmac", HS256)
+	assert.NoError(err)
+	assert.Equal(hmacSecret, key)
+
+	// Without a key ID, the first key usable with the algorithm is returned
+	key, err = keys.Key(context.Background(), "", ES256)
+	assert.NoError(err)
+	assert.IsType(&ecdsa.PublicKey{}, key)
+
+	// The key type must match the algorithm
+	_, err = keys.Key(context.Background(), "rsa", HS256)
+	assert.ErrorIs(err, errKeyNotFound)
+
+	// Encryption and unsupported keys are ignored
+	_, err = keys.Key(context.Background(), "enc", RS256)
+	assert.ErrorIs(err, errKeyNotFound)
+	_, err = keys.Key(context.Background(), "ed", ES256)
+	assert.ErrorIs(err, errKeyNotFound)
+
+	_, err = ParseJWKS([]byte("not json"))
+	assert.Error(err)
+
+	// An empty symmetric key is rejected, as anyone could sign with it
+	_, err = JWK{KeyType: "oct", K: ""}.PublicKey()
+	assert.ErrorIs(err, errEmptyKey)
+	keys, err = ParseJWKS(jwks(map[string]string{"kty": "oct", "kid": "empty", "k": ""}))
+	assert.NoError(err)
+	_, err = keys.Key(context.Background(), "empty", HS256)
+	assert.ErrorIs(err, errKeyNotFound)
+}
+
+func TestVerifySignature_EmptyKey(t *testing.T) {
+	signingInput := []byte("header.claims")
+	mac := hmac.New(sha256.New, nil)
+	mac.Write(signingInput)
+	assert.ErrorIs(t, verifySignature(HS256, []byte{}, signingInput, mac.Sum(nil)), errEmptyKey)
+	assert.ErrorIs(t, verifySignature(HS256, []byte(nil), signingInput, mac.Sum(nil)), errEmptyKey)
+}
+
+func TestJWKSProvider_FileSource(t *testing.T) {
+	assert := assert.New(t)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(os.WriteFile(path, jwks(rsaJWK("rsa")), 0o600))
+
+	provider := NewJWKSProvider(FileSource(path))
+	key, err := provider.Key(context.Background(), "rsa", RS256)
+	assert.NoError(err)
+	assert.IsType(&rsa.PublicKey{}, key)
+
+	_, err = NewJWKSProvider(FileSource(filepath.Join(t.TempDir(), "missing.json"))).Key(context.Background(), "rsa", RS256)
+	assert.Error(err)
+}
+
+func TestJWKSProvider_Rotation(t *testing.T) {
+	assert := assert.New(t)
+	current := now
+	document := jwks(rsaJWK("key-1"))
+	var fetchErr error
+	fetches := 0
+
+	source := JWKSSourceFunc(func(ctx context.Context) ([]byte, error) {
+		fetches++
+		return document, fetchErr
+	})
+	provider := NewJWKSProvider(source, WithCacheTTL(time.Hour), WithMinRefreshInterval(time.Minute))
+	provider.now = func() time.Time { return current }
+
+	// The document is fetched once and cached
+	_, err := provider.Key(context.Background(), "key-1", RS256)
+	assert.NoError(err)
+	_, err = provider.Key(context.Background(), "key-1", RS256)
+	assert.NoError(err)
+	assert.Equal(1, fetches)
+
+	// A new key is rotated in; unknown key IDs trigger a refresh only after the minimum interval
+	document = jwks(rsaJWK("key-1"), ecJWK("key-2"))
+	_, err = provider.Key(context.Background(), "key-2", ES256)
+	assert.ErrorIs(err, errKeyNotFound)
+	assert.Equal(1, fetches)
+
+	current = current.Add(2 * time.Minute)
+	key, err := provider.Key(context.Background(), "key-2", ES256)
+	assert.NoError(err)
+	assert.IsType(&ecdsa.PublicKey{}, key)
+	assert.Equal(2, fetches)
+
+	// After the TTL the document is fetched again, and stale keys are kept if the source fails
+	current = current.Add(2 * time.Hour)
+	fetchErr = errors.New("source unavailable")
+	_, err = provider.Key(context.Background(), "key-1", RS256)
+	assert.NoError(err)
+	assert.Equal(3, fetches)
+}
+
+func TestJWKSProvider_FailingSource(t *testing.T) {
+	assert := assert.New(t)
+	current := now
+	fetches := 0
+
+	source := JWKSSourceFunc(func(ctx context.Context) ([]byte, error) {
+		fetches++
+		if fetches > 1 {
+			return nil, errors.New("source unavailable")
+		}
+		return jwks(rsaJWK("key-1")), nil
+	})
+	provider := NewJWKSProvider(source, WithCacheTTL(time.Hour), WithMinRefreshInterval(time.Minute))
+	provider.now = func() time.Time { return current }
+
+	_, err := provider.Key(context.Background(), "key-1", RS256)
+	assert.NoError(err)
+	assert.Equal(1, fetches)
+
+	// Once the TTL has elapsed, the failed fetch is not retried on every lookup
+	current = current.Add(2 * time.Hour)
+	for range 10 {
+		_, err = provider.Key(context.Background(), "key-1", RS256)
+		assert.NoError(err)
+	}
+	assert.Equal(2, fetches)
+
+	// Unknown key IDs do not trigger a fetch within the minimum interval of the failed attempt
+	_, err = provider.Key(context.Background(), "key-2", RS256)
+	assert.ErrorIs(err, errKeyNotFound)
+	assert.Equal(2, fetches)
+
+	current = current.Add(2 * time.Minute)
+	_, err = provider.Key(context.Background(), "key-2", RS256)
+	assert.ErrorContains(err, "source unavailable")
+	assert.Equal(3, fetches)
+	_, err = provider.Key(context.Background(), "key-2", RS256)
+	assert.ErrorIs(err, errKeyNotFound)
+	assert.Equal(3, fetches)
+}
+
+func TestJWKSProvider_InitialFetchFails(t *testing.T) {
+	assert := assert.New(t)
+	current := now
+	fetches := 0
+
+	source := JWKSSourceFunc(func(ctx context.Context) ([]byte, error) {
+		fetches++
+		return nil, errors.New("source unavailable")
+	})
+	provider := NewJWKSProvider(source, WithMinRefreshInterval(time.Minute))
+	provider.now = func() time.Time { return current }
+
+	// Without any key, the error is returned and the fetch is retried after the minimum interval
+	for range 10 {
+		_, err := provider.Key(context.Background(), "key-1", RS256)
+		assert.ErrorContains(err, "source unavailable")
+	}
+	assert.Equal(1, fetches)
+
+	current = current.Add(2 * time.Minute)
+	_, err := provider.Key(context.Background(), "key-1", RS256)
+	assert.Error(err)
+	assert.Equal(2, fetches)
+}
+
+func TestJWKSProvider_WithJWT(t *testing.T) {
+	assert := assert.New(t)
+
+	provider := NewJWKSProvider(JWKSSourceFunc(func(ctx context.Context) ([]byte, error) {
+		return jwks(rsaJWK("rsa"), ecJWK("ec")), nil
+	}))
+
+	for _, tc := range []struct{ alg, kid string }{{RS256, "rsa"}, {ES256, "ec"}} {
+		claims, err := verify(context.Background(), &Config{
+			algorithms: []string{tc.alg},
+			now:        func() time.Time { return now },
+		}, provider, sign(t, tc.alg, tc.kid, validClaims()))
+		assert.NoError(err)
+		assert.Equal("user-1", claims.Subject)
+	}
+}
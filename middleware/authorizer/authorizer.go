@@ -0,0 +1,221 @@
+package authorizer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+)
+
+const (
+	// defaultErrorBody is the default response body when the request is not authenticated.
+	defaultErrorBody = "Unauthorized"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the default key type used to store the Principal within the context.
+type CtxKey struct{}
+
+// Principal is the caller identity established by an API Gateway authorizer.
+type Principal struct {
+	// Subject is the unique identifier of the caller ("sub" claim or the Lambda authorizer principalId).
+	Subject string
+	// Username is the human readable name of the caller ("cognito:username", "username" or "preferred_username" claim).
+	Username string
+	// Groups lists the groups of the caller ("cognito:groups" or "groups" claim).
+	Groups []string
+	// Scopes lists the OAuth scopes granted to the caller ("scope" or "scp" claim).
+	Scopes []string
+	// Claims holds the raw claims, or the Lambda authorizer context for Lambda authorizers.
+	Claims map[string]any
+}
+
+// Config is the configuration for the Extract and RequireAuthenticated middleware.
+type Config struct {
+	ctxKey           any
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the Extract and RequireAuthenticated configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the Principal to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned by RequireAuthenticated
+// when the request is not authenticated.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// FromRequest builds a Principal from RequestContext.Authorizer.
+//
+// The following authorizer shapes are understood:
+//   - Cognito user pool and JWT authorizers, which put the token claims under "claims"
+//   - JWT authorizers using the HTTP API payload shape, with "jwt": {"claims": ..., "scopes": ...}
+//   - Lambda authorizers, whose context entries and "principalId" are placed directly in the map
+//
+// List-valued claims are accepted as JSON arrays, as well as strings separated by commas or spaces,
+// optionally wrapped in brackets (e.g. "[admin support]"), which is how API Gateway flattens them.
+// The second return value is false if the request carries no identity.
+func FromRequest(request events.APIGatewayProxyRequest) (Principal, bool) {
+	authorizer := request.RequestContext.Authorizer
+	if len(authorizer) == 0 {
+		return Principal{}, false
+	}
+
+	claims, _ := authorizer["claims"].(map[string]any)
+	var scopes []string
+	if jwt, ok := authorizer["jwt"].(map[string]any); ok {
+		if c, ok := jwt["claims"].(map[string]any); ok {
+			claims = c
+		}
+		scopes = StringList(jwt["scopes"])
+	}
+	if claims == nil {
+		// Lambda authorizer: the context is the map itself
+		claims = make(map[string]any, len(authorizer))
+		for k, v := range authorizer {
+			claims[k] = v
+		}
+	}
+
+	p := Principal{
+		Subject:  firstString(claims, "sub", "principalId"),
+		Username: firstString(claims, "cognito:username", "username", "preferred_username"),
+		Groups:   firstList(claims, "cognito:groups", "groups"),
+		Scopes:   scopes,
+		Claims:   claims,
+	}
+	if p.Subject == "" {
+		p.Subject = firstString(authorizer, "principalId")
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = firstList(claims, "scope", "scp", "scopes")
+	}
+
+	if p.Subject == "" && p.Username == "" {
+		return Principal{}, false
+	}
+	return p, true
+}
+
+// Extract creates middleware that normalizes the API Gateway authorizer context into a Principal
+// and sets it in the context. Requests without an identity are passed through without a Principal.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, authorizer.Extract())
+//
+//	// In the handler
+//	principal, ok := ctx.Value(authorizer.CtxKey{}).(authorizer.Principal)
+func Extract(opts ...Option) middleware.MiddlewareFunc {
+	config := newConfig(opts)
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			if p, ok := FromRequest(request); ok {
+				ctx = context.WithValue(ctx, config.ctxKey, p)
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// RequireAuthenticated works like Extract, but returns 401 Unauthorized when the request carries no identity.
+func RequireAuthenticated(opts ...Option) middleware.MiddlewareFunc {
+	config := newConfig(opts)
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			p, ok := FromRequest(request)
+			if !ok {
+				return errorResponse, nil
+			}
+			return next(context.WithValue(ctx, config.ctxKey, p), request)
+		}
+	}
+}
+
+// newConfig builds the configuration from the default values and opts.
+func newConfig(opts []Option) Config {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// firstString returns the first non-empty value among the named claims, formatted as a string.
+func firstString(claims map[string]any, names ...string) string {
+	for _, name := range names {
+		v, ok := claims[name]
+		if !ok || v == nil {
+			continue
+		}
+		if s := fmt.Sprint(v); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// firstList returns the first non-empty list among the named claims.
+func firstList(claims map[string]any, names ...string) []string {
+	for _, name := range names {
+		if list := StringList(claims[name]); len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
+
+// StringList converts a list-valued claim to a slice of strings. Claims are passed by API Gateway
+// either as lists or as strings split on commas and spaces, optionally wrapped in brackets (e.g. "[admin editor]").
+func StringList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				list = append(list, fmt.Sprint(item))
+			}
+		}
+		return list
+	case string:
+		v = strings.TrimSpace(v)
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "["), "]")
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+	}
+	return nil
+}
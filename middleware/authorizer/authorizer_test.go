@@ -0,0 +1,224 @@
+package authorizer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRequest creates a request with the given authorizer context.
+func createRequest(authorizer map[string]interface{}) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: events.APIGatewayProxyRequestContext{Authorizer: authorizer},
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		authorizer map[string]interface{}
+		expected   Principal
+		expectOK   bool
+	}{
+		{
+			name: "Cognito user pool authorizer",
+			authorizer: map[string]interface{}{
+				"claims": map[string]interface{}{
+					"sub":              "1111-2222",
+					"cognito:username": "alice",
+					"cognito:groups":   "admin,support",
+					"email":            "alice@example.com",
+				},
+			},
+			expected: Principal{
+				Subject:  "1111-2222",
+				Username: "alice",
+				Groups:   []string{"admin", "support"},
+				Claims: map[string]any{
+					"sub":              "1111-2222",
+					"cognito:username": "alice",
+					"cognito:groups":   "admin,support",
+					"email":            "alice@example.com",
+				},
+			},
+			expectOK: true,
+		},
+		{
+			name: "Cognito access token with scopes",
+			authorizer: map[string]interface{}{
+				"claims": map[string]interface{}{
+					"sub":      "1111-2222",
+					"username": "alice",
+					"scope":    "orders:read orders:write",
+				},
+			},
+			expected: Principal{
+				Subject:  "1111-2222",
+				Username: "alice",
+				Scopes:   []string{"orders:read", "orders:write"},
+				Claims: map[string]any{
+					"sub":      "1111-2222",
+					"username": "alice",
+					"scope":    "orders:read orders:write",
+				},
+			},
+			expectOK: true,
+		},
+		{
+			name: "JWT authorizer (HTTP API shape)",
+			authorizer: map[string]interface{}{
+				"jwt": map[string]interface{}{
+					"claims": map[string]interface{}{
+						"sub":    "user-1",
+						"groups": "[admin support]",
+					},
+					"scopes": []interface{}{"orders:read"},
+				},
+			},
+			expected: Principal{
+				Subject: "user-1",
+				Groups:  []string{"admin", "support"},
+				Scopes:  []string{"orders:read"},
+				Claims: map[string]any{
+					"sub":    "user-1",
+					"groups": "[admin support]",
+				},
+			},
+			expectOK: true,
+		},
+		{
+			name: "Lambda authorizer",
+			authorizer: map[string]interface{}{
+				"principalId": "user-1",
+				"username":    "bob",
+				"scp":         []interface{}{"a", "b"},
+				"tenant":      "acme",
+			},
+			expected: Principal{
+				Subject:  "user-1",
+				Username: "bob",
+				Scopes:   []string{"a", "b"},
+				Claims: map[string]any{
+					"principalId": "user-1",
+					"username":    "bob",
+					"scp":         []interface{}{"a", "b"},
+					"tenant":      "acme",
+				},
+			},
+			expectOK: true,
+		},
+		{
+			name: "Claims without subject fall back to principalId",
+			authorizer: map[string]interface{}{
+				"principalId": "user-1",
+				"claims":      map[string]interface{}{"email": "a@example.com"},
+			},
+			expected: Principal{
+				Subject: "user-1",
+				Claims:  map[string]any{"email": "a@example.com"},
+			},
+			expectOK: true,
+		},
+		{
+			name:       "No authorizer",
+			authorizer: nil,
+			expectOK:   false,
+		},
+		{
+			name:       "Authorizer without identity",
+			authorizer: map[string]interface{}{"integrationLatency": 12},
+			expectOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := FromRequest(createRequest(tt.authorizer))
+			assert.Equal(t, tt.expectOK, ok)
+			assert.Equal(t, tt.expected, p)
+		})
+	}
+}
+
+func TestStringList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []string
+	}{
+		{"string slice", []string{"admin", "editor"}, []string{"admin", "editor"}},
+		{"JSON array", []any{"admin", nil, 42}, []string{"admin", "42"}},
+		{"space separated", "read write", []string{"read", "write"}},
+		{"bracketed", "[admin, editor]", []string{"admin", "editor"}},
+		{"empty", "", []string{}},
+		{"unsupported", 42, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, StringList(tt.value))
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	assert := assert.New(t)
+	var principal any
+
+	handler := Extract()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		principal = ctx.Value(CtxKey{})
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), createRequest(map[string]interface{}{"principalId": "user-1"}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("user-1", principal.(Principal).Subject)
+
+	// Anonymous requests are passed through
+	response, err = handler(context.Background(), createRequest(nil))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Nil(principal)
+}
+
+func TestRequireAuthenticated(t *testing.T) {
+	assert := assert.New(t)
+	type principalKey struct{}
+	nextCalled := false
+
+	handler := RequireAuthenticated(
+		WithCtxKey(principalKey{}),
+		WithResponse("application/json", `{"error":"unauthenticated"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		nextCalled = true
+		assert.Equal("alice", ctx.Value(principalKey{}).(Principal).Username)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), createRequest(map[string]interface{}{
+		"claims": map[string]interface{}{"sub": "1", "cognito:username": "alice"},
+	}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.True(nextCalled)
+
+	nextCalled = false
+	response, err = handler(context.Background(), createRequest(nil))
+	assert.NoError(err)
+	assert.False(nextCalled)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`{"error":"unauthenticated"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+
+	// Default response
+	response, err = RequireAuthenticated()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})(context.Background(), createRequest(nil))
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(defaultErrorBody, response.Body)
+	assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+}
@@ -0,0 +1,256 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/authorizer"
+)
+
+const (
+	// defaultErrorBody is the default response body when access is denied.
+	defaultErrorBody = "Forbidden"
+
+	// defaultUnauthorizedBody is the default response body when the request has no principal.
+	defaultUnauthorizedBody = "Unauthorized"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// Request is the input of a Rule.
+type Request struct {
+	// Principal is the authenticated caller.
+	Principal authorizer.Principal
+	// Roles are the roles of the caller, as returned by the roles function.
+	Roles []string
+	// Method is the HTTP method of the request.
+	Method string
+	// Resource is the API Gateway resource of the request (e.g. "/orders/{id}").
+	Resource string
+	// Attributes holds the path parameters of the request, for attribute-based rules.
+	Attributes map[string]string
+}
+
+// Rule decides whether a request is allowed.
+// Custom policies can be written as ordinary functions of this type.
+type Rule func(ctx context.Context, request Request) bool
+
+// RequireScopes returns a Rule that allows callers granted all of the given scopes.
+func RequireScopes(scopes ...string) Rule {
+	return func(ctx context.Context, request Request) bool {
+		for _, scope := range scopes {
+			if !slices.Contains(request.Principal.Scopes, scope) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// RequireAnyScope returns a Rule that allows callers granted at least one of the given scopes.
+func RequireAnyScope(scopes ...string) Rule {
+	return func(ctx context.Context, request Request) bool {
+		return containsAny(request.Principal.Scopes, scopes)
+	}
+}
+
+// RequireRole returns a Rule that allows callers having at least one of the given roles.
+func RequireRole(roles ...string) Rule {
+	return func(ctx context.Context, request Request) bool {
+		return containsAny(request.Roles, roles)
+	}
+}
+
+// RequireGroup returns a Rule that allows callers belonging to at least one of the given groups.
+func RequireGroup(groups ...string) Rule {
+	return func(ctx context.Context, request Request) bool {
+		return containsAny(request.Principal.Groups, groups)
+	}
+}
+
+// AllOf returns a Rule that allows a request only if all of the given rules allow it.
+func AllOf(rules ...Rule) Rule {
+	return func(ctx context.Context, request Request) bool {
+		for _, rule := range rules {
+			if !rule(ctx, request) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf returns a Rule that allows a request if at least one of the given rules allows it.
+func AnyOf(rules ...Rule) Rule {
+	return func(ctx context.Context, request Request) bool {
+		for _, rule := range rules {
+			if rule(ctx, request) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Denial describes a request rejected by the Authorize middleware.
+type Denial struct {
+	// Request is the rejected request. Its Principal is empty if the request was not authenticated.
+	Request Request
+	// Authenticated is false when the request carried no principal.
+	Authenticated bool
+}
+
+// AuditFunc is called for every request rejected by the Authorize middleware.
+type AuditFunc func(ctx context.Context, denial Denial)
+
+// RolesFunc returns the roles of a principal.
+type RolesFunc func(p authorizer.Principal) []string
+
+// DefaultRoles returns the groups of the principal, followed by the values of its "roles", "role"
+// and "custom:roles" claims.
+func DefaultRoles(p authorizer.Principal) []string {
+	roles := slices.Clone(p.Groups)
+	for _, name := range []string{"roles", "role", "custom:roles"} {
+		roles = append(roles, authorizer.StringList(p.Claims[name])...)
+	}
+	return roles
+}
+
+// Config is the configuration for the Authorize middleware.
+type Config struct {
+	principalCtxKey         any
+	rolesFunc               RolesFunc
+	auditFunc               AuditFunc
+	errorBody               string
+	errorContentType        string
+	unauthorizedBody        string
+	unauthorizedContentType string
+}
+
+// Option is a function type to modify the Authorize configuration.
+type Option func(*Config)
+
+// WithPrincipalCtxKey specifies the context key under which the authorizer.Principal is stored.
+// The default is authorizer.CtxKey{}.
+func WithPrincipalCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.principalCtxKey = ctxKey
+	}
+}
+
+// WithRolesFunc sets the function returning the roles of a principal. The default is DefaultRoles.
+func WithRolesFunc(fn RolesFunc) Option {
+	return func(c *Config) {
+		c.rolesFunc = fn
+	}
+}
+
+// WithAuditFunc sets a function called for every denied request, e.g. to write an audit log.
+func WithAuditFunc(fn AuditFunc) Option {
+	return func(c *Config) {
+		c.auditFunc = fn
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when access is denied (403).
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// WithUnauthorizedResponse sets the response Content-Type header and response body returned
+// when the request has no principal (401).
+func WithUnauthorizedResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.unauthorizedContentType = contentType
+		c.unauthorizedBody = body
+	}
+}
+
+// Authorize creates middleware that allows a request only if rule allows it.
+//
+// The caller is read from the authorizer.Principal stored in the context, so Authorize must run after
+// authorizer.Extract (or any middleware storing a Principal under the configured key).
+// Requests without a principal are rejected with 401 Unauthorized, and requests denied by rule with 403 Forbidden.
+//
+// Example:
+//
+//	// Requires the orders:write scope, and either the admin or the support role
+//	authz.Authorize(authz.AllOf(authz.RequireScopes("orders:write"), authz.RequireRole("admin", "support")))
+//
+//	// Attribute-based rule: callers may only access their own orders
+//	authz.Authorize(func(ctx context.Context, r authz.Request) bool {
+//	    return r.Attributes["userId"] == r.Principal.Subject
+//	})
+func Authorize(rule Rule, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		principalCtxKey:         authorizer.CtxKey{},
+		rolesFunc:               DefaultRoles,
+		errorBody:               defaultErrorBody,
+		errorContentType:        defaultErrorContentType,
+		unauthorizedBody:        defaultUnauthorizedBody,
+		unauthorizedContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Prepare error responses
+	forbiddenResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusForbidden,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+	unauthorizedResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.unauthorizedBody,
+		Headers:    map[string]string{"Content-Type": config.unauthorizedContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			authzRequest := Request{
+				Method:     request.HTTPMethod,
+				Resource:   request.Resource,
+				Attributes: request.PathParameters,
+			}
+
+			p, ok := ctx.Value(config.principalCtxKey).(authorizer.Principal)
+			if !ok {
+				if config.auditFunc != nil {
+					config.auditFunc(ctx, Denial{Request: authzRequest, Authenticated: false})
+				}
+				return unauthorizedResponse, nil
+			}
+			authzRequest.Principal = p
+			authzRequest.Roles = config.rolesFunc(p)
+
+			if !rule(ctx, authzRequest) {
+				if config.auditFunc != nil {
+					config.auditFunc(ctx, Denial{Request: authzRequest, Authenticated: true})
+				}
+				return forbiddenResponse, nil
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// containsAny reports whether values contains at least one of wanted.
+func containsAny(values, wanted []string) bool {
+	for _, w := range wanted {
+		if slices.Contains(values, w) {
+			return true
+		}
+	}
+	return false
+}
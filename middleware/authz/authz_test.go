@@ -0,0 +1,154 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/authorizer"
+	"github.com/stretchr/testify/assert"
+)
+
+// okHandler is a handler that always returns 200 OK.
+func okHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+}
+
+// withPrincipal returns a context holding p under the default key.
+func withPrincipal(p authorizer.Principal) context.Context {
+	return context.WithValue(context.Background(), authorizer.CtxKey{}, p)
+}
+
+func TestRules(t *testing.T) {
+	request := Request{
+		Principal: authorizer.Principal{
+			Subject: "user-1",
+			Groups:  []string{"support"},
+			Scopes:  []string{"orders:read", "orders:write"},
+		},
+		Roles: []string{"support", "auditor"},
+	}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		expected bool
+	}{
+		{"All scopes granted", RequireScopes("orders:read", "orders:write"), true},
+		{"Missing scope", RequireScopes("orders:read", "orders:delete"), false},
+		{"Any scope", RequireAnyScope("orders:delete", "orders:read"), true},
+		{"No scope", RequireAnyScope("orders:delete"), false},
+		{"Role", RequireRole("admin", "auditor"), true},
+		{"Missing role", RequireRole("admin"), false},
+		{"Group", RequireGroup("support"), true},
+		{"Missing group", RequireGroup("auditor"), false},
+		{"AllOf", AllOf(RequireScopes("orders:read"), RequireRole("support")), true},
+		{"AllOf denied", AllOf(RequireScopes("orders:read"), RequireRole("admin")), false},
+		{"AnyOf", AnyOf(RequireRole("admin"), RequireGroup("support")), true},
+		{"AnyOf denied", AnyOf(RequireRole("admin"), RequireGroup("admin")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rule(context.Background(), request))
+		})
+	}
+}
+
+func TestDefaultRoles(t *testing.T) {
+	roles := DefaultRoles(authorizer.Principal{
+		Groups: []string{"support"},
+		Claims: map[string]any{"roles": []any{"admin"}, "custom:roles": "auditor,viewer"},
+	})
+	assert.Equal(t, []string{"support", "admin", "auditor", "viewer"}, roles)
+}
+
+func TestAuthorize(t *testing.T) {
+	assert := assert.New(t)
+	var denials []Denial
+
+	// Attribute-based rule: admins, or callers accessing their own resources
+	rule := AnyOf(RequireRole("admin"), func(ctx context.Context, r Request) bool {
+		return r.Attributes["userId"] == r.Principal.Subject
+	})
+	handler := Authorize(rule, WithAuditFunc(func(ctx context.Context, denial Denial) {
+		denials = append(denials, denial)
+	}))(okHandler)
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:     http.MethodGet,
+		Resource:       "/users/{userId}",
+		PathParameters: map[string]string{"userId": "user-1"},
+	}
+
+	// Own resource
+	response, err := handler(withPrincipal(authorizer.Principal{Subject: "user-1"}), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// Admin role from the "role" claim
+	response, err = handler(withPrincipal(authorizer.Principal{Subject: "user-2", Claims: map[string]any{"role": "admin"}}), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Empty(denials)
+
+	// Other user's resource
+	response, err = handler(withPrincipal(authorizer.Principal{Subject: "user-2"}), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+	assert.Equal(defaultErrorBody, response.Body)
+	assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+
+	// No principal
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(defaultUnauthorizedBody, response.Body)
+
+	if assert.Len(denials, 2) {
+		assert.True(denials[0].Authenticated)
+		assert.Equal("user-2", denials[0].Request.Principal.Subject)
+		assert.Equal("/users/{userId}", denials[0].Request.Resource)
+		assert.Equal(http.MethodGet, denials[0].Request.Method)
+		assert.False(denials[1].Authenticated)
+	}
+}
+
+func TestAuthorize_Options(t *testing.T) {
+	assert := assert.New(t)
+	type principalKey struct{}
+
+	handler := Authorize(
+		RequireRole("admin"),
+		WithPrincipalCtxKey(principalKey{}),
+		WithRolesFunc(func(p authorizer.Principal) []string { return []string{p.Claims["tenantRole"].(string)} }),
+		WithResponse("application/json", `{"error":"forbidden"}`),
+		WithUnauthorizedResponse("application/json", `{"error":"unauthorized"}`),
+	)(okHandler)
+
+	ctx := context.WithValue(context.Background(), principalKey{}, authorizer.Principal{
+		Subject: "user-1",
+		Claims:  map[string]any{"tenantRole": "admin"},
+	})
+	response, err := handler(ctx, events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	ctx = context.WithValue(context.Background(), principalKey{}, authorizer.Principal{
+		Subject: "user-1",
+		Claims:  map[string]any{"tenantRole": "viewer"},
+	})
+	response, err = handler(ctx, events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+	assert.Equal(`{"error":"forbidden"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+
+	// The principal under the default key is ignored
+	response, err = handler(withPrincipal(authorizer.Principal{Subject: "user-1"}), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`{"error":"unauthorized"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
@@ -0,0 +1,133 @@
+package cachecontrol
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/route"
+)
+
+// Rule describes the caching headers applied to responses matching its conditions.
+// Empty conditions match any request or response.
+type Rule struct {
+	// Methods is the list of HTTP methods the rule applies to (e.g. "GET", "HEAD").
+	Methods []string
+	// Resource is a pattern matched against the API Gateway resource (e.g. "/users/{id}") of the request.
+	// The pattern syntax is that of path.Match, so "/users/*" matches "/users/{id}".
+	// A pattern ending with "/**" matches every resource under the prefix.
+	Resource string
+	// StatusCodes is the list of response status codes the rule applies to.
+	StatusCodes []int
+
+	// CacheControl is the value of the Cache-Control header (e.g. "public, max-age=300").
+	CacheControl string
+	// Expires sets the Expires header to the current time plus the given duration, if positive.
+	Expires time.Duration
+	// Vary is the list of request header names added to the Vary header.
+	Vary []string
+	// SurrogateControl is the value of the Surrogate-Control header used by CDNs.
+	SurrogateControl string
+}
+
+// Config is the configuration for the CacheControl middleware.
+type Config struct {
+	rules []Rule
+	now   func() time.Time
+}
+
+// Option is a function type to modify the CacheControl configuration.
+type Option func(*Config)
+
+// WithClock sets the function returning the current time, used to compute the Expires header.
+// By default, time.Now is used.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// CacheControl creates middleware that applies caching headers to responses based on the given rules.
+//
+// Rules are evaluated in order and the first rule matching the request method, the request resource
+// and the response status code is applied. Headers already set by the handler are never overridden:
+// Cache-Control, Expires and Surrogate-Control are only added when absent, and Vary only gains the
+// field names it does not list yet.
+//
+// Responses returned together with an error are passed through unchanged.
+//
+// Example:
+//
+//	cachecontrol.CacheControl([]cachecontrol.Rule{
+//	    {Methods: []string{"GET", "HEAD"}, Resource: "/products/*", StatusCodes: []int{200},
+//	        CacheControl: "public, max-age=300", SurrogateControl: "max-age=3600", Vary: []string{"Accept-Language"}},
+//	    {CacheControl: "no-store"},
+//	})
+func CacheControl(rules []Rule, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		rules: rules,
+		now:   time.Now,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			for i := range config.rules {
+				rule := &config.rules[i]
+				if !rule.matches(&request, response.StatusCode) {
+					continue
+				}
+
+				if rule.CacheControl != "" {
+					header.SetDefault(&response, "Cache-Control", rule.CacheControl)
+				}
+				if rule.Expires > 0 {
+					header.SetDefault(&response, "Expires", config.now().Add(rule.Expires).UTC().Format(http.TimeFormat))
+				}
+				if rule.SurrogateControl != "" {
+					header.SetDefault(&response, "Surrogate-Control", rule.SurrogateControl)
+				}
+				for _, field := range rule.Vary {
+					header.AddVary(&response, field)
+				}
+				break
+			}
+
+			return response, nil
+		}
+	}
+}
+
+// matches reports whether the rule applies to the request and the response status code.
+func (r *Rule) matches(request *events.APIGatewayProxyRequest, statusCode int) bool {
+	if !route.Match(request, r.Methods, r.Resource) {
+		return false
+	}
+
+	if len(r.StatusCodes) > 0 {
+		found := false
+		for _, code := range r.StatusCodes {
+			if code == statusCode {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
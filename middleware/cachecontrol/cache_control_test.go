@@ -0,0 +1,172 @@
+package cachecontrol
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// fixedNow is the current time used by the tests.
+var fixedNow = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// testRules is the rule set used by TestCacheControl.
+var testRules = []Rule{
+	{
+		Methods:          []string{"GET", "HEAD"},
+		Resource:         "/products/*",
+		StatusCodes:      []int{http.StatusOK},
+		CacheControl:     "public, max-age=300",
+		Expires:          5 * time.Minute,
+		Vary:             []string{"Accept-Language"},
+		SurrogateControl: "max-age=3600",
+	},
+	{
+		Methods:      []string{"GET"},
+		Resource:     "/static/**",
+		CacheControl: "public, max-age=86400, immutable",
+	},
+	{
+		StatusCodes:  []int{http.StatusNotFound},
+		CacheControl: "public, max-age=10",
+	},
+	{
+		CacheControl: "no-store",
+	},
+}
+
+// newHandler returns a handler that responds with the given status code and headers.
+func newHandler(statusCode int, headers map[string]string) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: statusCode, Headers: headers, Body: "body"}, nil
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name            string
+		method          string
+		resource        string
+		statusCode      int
+		handlerHeaders  map[string]string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:       "First rule",
+			method:     "GET",
+			resource:   "/products/{id}",
+			statusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Cache-Control":     "public, max-age=300",
+				"Expires":           "Tue, 02 Jan 2024 03:09:05 GMT",
+				"Vary":              "Accept-Language",
+				"Surrogate-Control": "max-age=3600",
+			},
+		},
+		{
+			name:       "Method mismatch falls through",
+			method:     "POST",
+			resource:   "/products/{id}",
+			statusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "no-store",
+			},
+		},
+		{
+			name:       "Status mismatch falls through",
+			method:     "GET",
+			resource:   "/products/{id}",
+			statusCode: http.StatusNotFound,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "public, max-age=10",
+			},
+		},
+		{
+			name:       "Prefix pattern",
+			method:     "get",
+			resource:   "/static/css/{file}",
+			statusCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Cache-Control": "public, max-age=86400, immutable",
+			},
+		},
+		{
+			name:       "Handler headers are kept",
+			method:     "GET",
+			resource:   "/products/{id}",
+			statusCode: http.StatusOK,
+			handlerHeaders: map[string]string{
+				"cache-control": "private",
+				"Vary":          "Origin",
+			},
+			expectedHeaders: map[string]string{
+				"cache-control":     "private",
+				"Expires":           "Tue, 02 Jan 2024 03:09:05 GMT",
+				"Vary":              "Origin, Accept-Language",
+				"Surrogate-Control": "max-age=3600",
+			},
+		},
+		{
+			name:       "Vary already listed",
+			method:     "GET",
+			resource:   "/products/{id}",
+			statusCode: http.StatusOK,
+			handlerHeaders: map[string]string{
+				"Vary": "accept-language",
+			},
+			expectedHeaders: map[string]string{
+				"Cache-Control":     "public, max-age=300",
+				"Expires":           "Tue, 02 Jan 2024 03:09:05 GMT",
+				"Vary":              "accept-language",
+				"Surrogate-Control": "max-age=3600",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mw := CacheControl(testRules, WithClock(func() time.Time { return fixedNow }))
+			handler := mw(newHandler(tt.statusCode, tt.handlerHeaders))
+
+			response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod: tt.method,
+				Resource:   tt.resource,
+			})
+
+			assert.NoError(err)
+			assert.Equal(tt.statusCode, response.StatusCode)
+			assert.Equal(tt.expectedHeaders, response.Headers)
+		})
+	}
+}
+
+func TestCacheControl_NoMatchingRule(t *testing.T) {
+	assert := assert.New(t)
+
+	mw := CacheControl([]Rule{{Resource: "/products/*", CacheControl: "public"}})
+	handler := mw(newHandler(http.StatusOK, nil))
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: "/users/{id}"})
+	assert.NoError(err)
+	assert.Nil(response.Headers)
+}
+
+func TestCacheControl_HandlerError(t *testing.T) {
+	assert := assert.New(t)
+	handlerErr := errors.New("handler failed")
+
+	mw := CacheControl([]Rule{{CacheControl: "public"}})
+	handler := mw(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, handlerErr
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.Equal(handlerErr, err)
+	assert.Nil(response.Headers)
+}
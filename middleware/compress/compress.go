@@ -0,0 +1,277 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/accept"
+)
+
+const (
+	// defaultMinSize is the default minimum body size (in bytes) for a response to be compressed.
+	defaultMinSize = 1024
+)
+
+// defaultContentTypes is the default list of media type patterns considered compressible.
+var defaultContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/*+json",
+	"application/xml",
+	"application/*+xml",
+	"application/javascript",
+	"application/x-javascript",
+	"image/svg+xml",
+}
+
+// Encoder compresses a response body for a single content coding.
+type Encoder interface {
+	// Encode returns the compressed form of data.
+	Encode(data []byte) ([]byte, error)
+}
+
+// EncoderFunc is an adapter to allow the use of ordinary functions as an Encoder.
+type EncoderFunc func(data []byte) ([]byte, error)
+
+// Encode calls f(data).
+func (f EncoderFunc) Encode(data []byte) ([]byte, error) {
+	return f(data)
+}
+
+// Config is the configuration for the Compress middleware.
+type Config struct {
+	level        int
+	minSize      int
+	contentTypes []string
+	codings      []string
+	encoders     map[string]Encoder
+}
+
+// Option is a function type to modify the Compress configuration.
+type Option func(*Config)
+
+// WithLevel sets the compression level used by the built-in gzip and deflate encoders.
+// The value is passed through to compress/flate, so flate.DefaultCompression,
+// flate.BestSpeed, flate.BestCompression etc. can be used.
+func WithLevel(level int) Option {
+	return func(c *Config) {
+		c.level = level
+	}
+}
+
+// WithMinSize sets the minimum response body size (in bytes) to be compressed.
+// Smaller bodies are returned as is. The default is 1024 bytes.
+func WithMinSize(size int) Option {
+	return func(c *Config) {
+		c.minSize = size
+	}
+}
+
+// WithContentTypes replaces the list of media types considered compressible.
+// Patterns may use "*" as the type or subtype (e.g. "text/*") and "*+suffix" as the subtype (e.g. "application/*+json").
+func WithContentTypes(contentTypes []string) Option {
+	return func(c *Config) {
+		c.contentTypes = contentTypes
+	}
+}
+
+// WithEncoder registers an Encoder for the given content coding (e.g. "br").
+// Registering a coding that is already known replaces its encoder.
+// Custom codings are preferred over the built-in gzip and deflate codings when
+// the client assigns them the same quality value.
+func WithEncoder(coding string, encoder Encoder) Option {
+	return func(c *Config) {
+		coding = strings.ToLower(coding)
+		if _, ok := c.encoders[coding]; !ok {
+			c.codings = append([]string{coding}, c.codings...)
+		}
+		c.encoders[coding] = encoder
+	}
+}
+
+// Compress creates middleware that compresses the response body according to the request's Accept-Encoding header.
+//
+// The built-in encoders support the "gzip" and "deflate" content codings. Other codings such as "br"
+// can be added with the WithEncoder option.
+//
+// A response is compressed only if all of the following hold:
+//   - the handler returned no error and the response has no Content-Encoding header yet
+//   - the response Content-Type matches one of the compressible media types
+//   - the (base64-decoded) body is at least the minimum size (1024 bytes by default)
+//   - the client accepts one of the supported codings
+//   - the compressed body is smaller than the original
+//
+// Compressed bodies are base64 encoded and IsBase64Encoded is set to true, as required by API Gateway.
+// Note that a REST API only decodes such bodies when the requested media type is listed in its binary media types.
+// Vary: Accept-Encoding is added to every response whose representation depends on the negotiation.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, compress.Compress(compress.WithMinSize(512)))
+func Compress(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		level:        flate.DefaultCompression,
+		minSize:      defaultMinSize,
+		contentTypes: defaultContentTypes,
+		codings:      []string{"gzip", "deflate"},
+		encoders:     map[string]Encoder{},
+	}
+	config.encoders["gzip"] = EncoderFunc(func(data []byte) ([]byte, error) {
+		return gzipEncode(data, config.level)
+	})
+	config.encoders["deflate"] = EncoderFunc(func(data []byte) ([]byte, error) {
+		return deflateEncode(data, config.level)
+	})
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			if !isCompressible(&config, &response) {
+				return response, nil
+			}
+
+			body := []byte(response.Body)
+			if response.IsBase64Encoded {
+				decoded, err := base64.StdEncoding.DecodeString(response.Body)
+				if err != nil {
+					// Leave a malformed body untouched rather than corrupting it further
+					return response, nil
+				}
+				body = decoded
+			}
+			if len(body) < config.minSize {
+				return response, nil
+			}
+
+			// From here on the representation depends on Accept-Encoding
+			header.AddVary(&response, "Accept-Encoding")
+
+			coding := negotiate(strings.Join(header.Request(&request).Values("Accept-Encoding"), ", "), config.codings)
+			if coding == "" {
+				return response, nil
+			}
+
+			compressed, err := config.encoders[coding].Encode(body)
+			if err != nil || len(compressed) >= len(body) {
+				// Compression is an optimization, so fall back to the uncompressed body
+				return response, nil
+			}
+
+			header.Set(&response, "Content-Encoding", coding)
+			header.Del(&response, "Content-Length")
+			response.Body = base64.StdEncoding.EncodeToString(compressed)
+			response.IsBase64Encoded = true
+			return response, nil
+		}
+	}
+}
+
+// isCompressible reports whether the response is a candidate for compression, regardless of its body size.
+func isCompressible(config *Config, response *events.APIGatewayProxyResponse) bool {
+	if response.StatusCode < http.StatusOK ||
+		response.StatusCode == http.StatusNoContent ||
+		response.StatusCode == http.StatusNotModified {
+		return false
+	}
+	h := header.Response(response)
+	if response.Body == "" || h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, pattern := range config.contentTypes {
+		if accept.MatchMediaType(strings.ToLower(pattern), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiate selects the content coding to use from the Accept-Encoding header value.
+// codings is the list of supported codings in order of server preference.
+// It returns an empty string if no supported coding is acceptable or the header is absent.
+func negotiate(acceptEncoding string, codings []string) string {
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return ""
+	}
+
+	// Collect the quality value of each listed coding
+	qvalues := make(map[string]float64)
+	for _, r := range accept.ParseTokens(acceptEncoding) {
+		qvalues[r.Value] = r.Q
+	}
+
+	best := ""
+	bestQ := 0.0
+	for _, coding := range codings {
+		q, ok := qvalues[coding]
+		if !ok {
+			// A coding that is not listed is acceptable only through the "*" wildcard
+			q, ok = qvalues["*"]
+			if !ok {
+				continue
+			}
+		}
+		// Strict comparison keeps the server preference on ties
+		if q > bestQ {
+			best = coding
+			bestQ = q
+		}
+	}
+	return best
+}
+
+// gzipEncode compresses data with the gzip coding.
+func gzipEncode(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// deflateEncode compresses data with the deflate coding.
+// The "deflate" content coding is the zlib format (RFC 1950), not a raw deflate stream.
+func deflateEncode(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := zlib.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,319 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// largeJSON is a compressible body larger than the default minimum size.
+var largeJSON = `{"items":[` + strings.Repeat(`{"name":"item","value":12345},`, 100) + `{}]}`
+
+// newHandler returns a handler that responds with the given content type and body.
+func newHandler(contentType, body string) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Content-Type": contentType},
+			Body:       body,
+		}, nil
+	}
+}
+
+// createRequest creates an APIGatewayProxyRequest with the given Accept-Encoding header.
+func createRequest(acceptEncoding string) events.APIGatewayProxyRequest {
+	headers := make(map[string]string)
+	if acceptEncoding != "" {
+		headers["Accept-Encoding"] = acceptEncoding
+	}
+	return events.APIGatewayProxyRequest{HTTPMethod: "GET", Headers: headers}
+}
+
+// decodeBody decompresses a base64 encoded response body with the given coding.
+func decodeBody(t *testing.T, coding, body string) string {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		t.Fatalf("body is not base64: %v", err)
+	}
+	var r io.Reader
+	switch coding {
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(raw))
+	case "deflate":
+		r, err = zlib.NewReader(bytes.NewReader(raw))
+	default:
+		t.Fatalf("unexpected coding %q", coding)
+	}
+	if err != nil {
+		t.Fatalf("failed to create reader: %v", err)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to decompress body: %v", err)
+	}
+	return string(decoded)
+}
+
+func TestCompress(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		expectedCoding string
+		expectVary     bool
+	}{
+		{
+			name:           "gzip accepted",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           largeJSON,
+			expectedCoding: "gzip",
+			expectVary:     true,
+		},
+		{
+			name:           "deflate accepted",
+			acceptEncoding: "deflate",
+			contentType:    "application/json; charset=utf-8",
+			body:           largeJSON,
+			expectedCoding: "deflate",
+			expectVary:     true,
+		},
+		{
+			name:           "Server preference on equal quality",
+			acceptEncoding: "deflate, gzip",
+			contentType:    "application/json",
+			body:           largeJSON,
+			expectedCoding: "gzip",
+			expectVary:     true,
+		},
+		{
+			name:           "Higher quality wins",
+			acceptEncoding: "gzip;q=0.5, deflate;q=0.8",
+			contentType:    "application/json",
+			body:           largeJSON,
+			expectedCoding: "deflate",
+			expectVary:     true,
+		},
+		{
+			name:           "Wildcard",
+			acceptEncoding: "*",
+			contentType:    "text/html",
+			body:           largeJSON,
+			expectedCoding: "gzip",
+			expectVary:     true,
+		},
+		{
+			name:           "Coding explicitly refused",
+			acceptEncoding: "gzip;q=0, deflate;q=0",
+			contentType:    "application/json",
+			body:           largeJSON,
+			expectedCoding: "",
+			expectVary:     true,
+		},
+		{
+			name:           "Unsupported coding only",
+			acceptEncoding: "br",
+			contentType:    "application/json",
+			body:           largeJSON,
+			expectedCoding: "",
+			expectVary:     true,
+		},
+		{
+			name:           "No Accept-Encoding header",
+			acceptEncoding: "",
+			contentType:    "application/json",
+			body:           largeJSON,
+			expectedCoding: "",
+			expectVary:     true,
+		},
+		{
+			name:           "Suffix pattern",
+			acceptEncoding: "gzip",
+			contentType:    "application/problem+json",
+			body:           largeJSON,
+			expectedCoding: "gzip",
+			expectVary:     true,
+		},
+		{
+			name:           "Not compressible content type",
+			acceptEncoding: "gzip",
+			contentType:    "image/png",
+			body:           largeJSON,
+			expectedCoding: "",
+			expectVary:     false,
+		},
+		{
+			name:           "Body below threshold",
+			acceptEncoding: "gzip",
+			contentType:    "application/json",
+			body:           `{"small":true}`,
+			expectedCoding: "",
+			expectVary:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			handler := Compress()(newHandler(tt.contentType, tt.body))
+			response, err := handler(context.Background(), createRequest(tt.acceptEncoding))
+
+			assert.NoError(err)
+			assert.Equal(http.StatusOK, response.StatusCode)
+			if tt.expectVary {
+				assert.Equal("Accept-Encoding", response.Headers["Vary"])
+			} else {
+				assert.Empty(response.Headers["Vary"])
+			}
+
+			if tt.expectedCoding == "" {
+				assert.Empty(response.Headers["Content-Encoding"])
+				assert.False(response.IsBase64Encoded)
+				assert.Equal(tt.body, response.Body)
+				return
+			}
+			assert.Equal(tt.expectedCoding, response.Headers["Content-Encoding"])
+			assert.True(response.IsBase64Encoded)
+			assert.Equal(tt.body, decodeBody(t, tt.expectedCoding, response.Body))
+		})
+	}
+}
+
+func TestCompress_Base64EncodedResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := Compress()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode:      http.StatusOK,
+			Headers:         map[string]string{"Content-Type": "text/csv"},
+			Body:            base64.StdEncoding.EncodeToString([]byte(largeJSON)),
+			IsBase64Encoded: true,
+		}, nil
+	})
+	response, err := handler(context.Background(), createRequest("gzip"))
+
+	assert.NoError(err)
+	assert.Equal("gzip", response.Headers["Content-Encoding"])
+	assert.True(response.IsBase64Encoded)
+	assert.Equal(largeJSON, decodeBody(t, "gzip", response.Body))
+}
+
+func TestCompress_ExistingHeaders(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := Compress()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				"content-type":   "application/json",
+				"vary":           "Origin",
+				"Content-Length": "3002",
+			},
+			Body: largeJSON,
+		}, nil
+	})
+	response, err := handler(context.Background(), createRequest("gzip"))
+
+	assert.NoError(err)
+	assert.Equal("gzip", response.Headers["Content-Encoding"])
+	assert.Equal("Origin, Accept-Encoding", response.Headers["Vary"])
+	assert.NotContains(response.Headers, "vary")
+	assert.NotContains(response.Headers, "Content-Length")
+}
+
+func TestCompress_AlreadyEncoded(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := Compress()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers: map[string]string{
+				"Content-Type":     "application/json",
+				"Content-Encoding": "identity",
+			},
+			Body: largeJSON,
+		}, nil
+	})
+	response, err := handler(context.Background(), createRequest("gzip"))
+
+	assert.NoError(err)
+	assert.Equal("identity", response.Headers["Content-Encoding"])
+	assert.Equal(largeJSON, response.Body)
+}
+
+func TestCompress_HandlerError(t *testing.T) {
+	assert := assert.New(t)
+	handlerErr := errors.New("handler failed")
+
+	handler := Compress()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       largeJSON,
+		}, handlerErr
+	})
+	response, err := handler(context.Background(), createRequest("gzip"))
+
+	assert.Equal(handlerErr, err)
+	assert.Equal(largeJSON, response.Body)
+	assert.Empty(response.Headers["Content-Encoding"])
+}
+
+func TestCompress_Options(t *testing.T) {
+	assert := assert.New(t)
+
+	// A fake "br" encoder that only marks the body
+	brEncoder := EncoderFunc(func(data []byte) ([]byte, error) {
+		return []byte("br"), nil
+	})
+
+	handler := Compress(
+		WithEncoder("br", brEncoder),
+		WithMinSize(10),
+		WithContentTypes([]string{"application/octet-stream"}),
+	)(newHandler("application/octet-stream", "0123456789abcdef"))
+
+	// The custom coding is preferred on equal quality
+	response, err := handler(context.Background(), createRequest("gzip, br"))
+	assert.NoError(err)
+	assert.Equal("br", response.Headers["Content-Encoding"])
+	assert.Equal(base64.StdEncoding.EncodeToString([]byte("br")), response.Body)
+
+	// Built-in codings are still available
+	response, err = handler(context.Background(), createRequest("gzip;q=1, br;q=0.1"))
+	assert.NoError(err)
+	assert.NotEqual("br", response.Headers["Content-Encoding"])
+}
+
+func TestNegotiate(t *testing.T) {
+	codings := []string{"gzip", "deflate"}
+
+	assert.Equal(t, "gzip", negotiate("GZIP", codings))
+	assert.Equal(t, "deflate", negotiate("gzip;q=0, *;q=0.5", codings))
+	assert.Equal(t, "", negotiate("identity", codings))
+	assert.Equal(t, "", negotiate("*;q=0", codings))
+	assert.Equal(t, "gzip", negotiate(" deflate ; q=0.2 , gzip ; q=0.3 ", codings))
+}
+
+func TestIsCompressible_StructuredSuffix(t *testing.T) {
+	config := &Config{contentTypes: []string{"application/*+json"}}
+	response := func(contentType string) *events.APIGatewayProxyResponse {
+		return &events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": contentType}, Body: "{}"}
+	}
+	assert.True(t, isCompressible(config, response("application/vnd.api+json")))
+	// A suffix without a name before it is not a structured syntax suffix
+	assert.False(t, isCompressible(config, response("application/+json")))
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/route"
 )
 
 const (
@@ -18,9 +20,46 @@ const (
 	defaultErrorContentType = "text/plain; charset=utf-8"
 )
 
+// EmptyBody is the policy applied to requests with an empty body.
+type EmptyBody int
+
+const (
+	// EmptyBodyInherit makes a Rule use the policy given with WithEmptyBody. As a config-level policy,
+	// it is the same as EmptyBodyCheck.
+	EmptyBodyInherit EmptyBody = iota
+
+	// EmptyBodyCheck validates the Content-Type of requests with an empty body like any other request,
+	// so that requests without a Content-Type header are rejected. This is the default.
+	EmptyBodyCheck
+
+	// EmptyBodyAllow accepts requests with an empty body whatever their Content-Type.
+	EmptyBodyAllow
+)
+
+// Rule describes the Content-Types allowed for requests matching its methods.
+type Rule struct {
+	// Methods is the list of HTTP methods the rule applies to (e.g. "POST"). If empty, the rule applies to any method.
+	Methods []string
+	// ContentTypes is the list of allowed media type patterns, with the same syntax as the allowlist
+	// of AllowContentType. If empty, every request with a body is rejected.
+	ContentTypes []string
+	// EmptyBody is the policy applied to requests with an empty body. The default is the config-level policy.
+	EmptyBody EmptyBody
+}
+
+// compiledRule is a Rule with parsed patterns.
+type compiledRule struct {
+	methods   []string
+	patterns  []pattern
+	emptyBody EmptyBody
+}
+
 // Config is the configuration for the AllowContentType middleware.
 type Config struct {
 	allowedTypes     []string
+	rules            []Rule
+	emptyBody        EmptyBody
+	sniff            bool
 	errorBody        string
 	errorContentType string
 }
@@ -28,6 +67,30 @@ type Config struct {
 // Option is a function type to modify the AllowContentType configuration.
 type Option func(*Config)
 
+// WithRules sets per-method rules. The first rule matching the method of the request replaces
+// the allowlist given to AllowContentType.
+func WithRules(rules ...Rule) Option {
+	return func(c *Config) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// WithEmptyBody sets the policy applied to requests with an empty body. The default is EmptyBodyCheck.
+func WithEmptyBody(policy EmptyBody) Option {
+	return func(c *Config) {
+		c.emptyBody = policy
+	}
+}
+
+// WithSniff enables or disables the detection of the actual type of the request body.
+// When enabled, requests whose body does not match their declared Content-Type are rejected,
+// e.g. an executable uploaded as image/png. Base64-encoded bodies are decoded first. Disabled by default.
+func WithSniff(enable bool) Option {
+	return func(c *Config) {
+		c.sniff = enable
+	}
+}
+
 // WithResponse sets the response Content-Type header and response body for error cases.
 func WithResponse(contentType string, body string) Option {
 	return func(c *Config) {
@@ -43,16 +106,30 @@ func WithResponse(contentType string, body string) Option {
 // The response body can be customized with the WithResponse option.
 //
 // If the contentTypes list is empty, all Content-Types will be rejected.
-// Content-Type comparison is done only on the media type part, parameters (e.g., charset=utf-8) are ignored.
-// Comparison is case-insensitive.
+// Entries of the list are patterns: the type and subtype may be "*" (e.g. "text/*" or "*/*"), and the subtype
+// may be a structured syntax suffix pattern (e.g. "application/*+json" allows "application/vnd.api+json").
+// Parameters of the Content-Type are ignored unless the pattern specifies them: "application/json; charset=utf-8"
+// requires the charset parameter to be utf-8. Comparison is case-insensitive.
+//
+// WithRules sets different allowlists per HTTP method, and WithEmptyBody (or Rule.EmptyBody) allows requests
+// with an empty body to omit the Content-Type header. WithSniff also checks that the body content matches
+// the declared Content-Type.
 //
 // Examples:
 // AllowContentType([]string{"application/json"}) allows "application/json" and "application/json; charset=utf-8".
 // AllowContentType([]string{"application/json", "application/xml"}) allows both JSON and XML.
+//
+// The following requires a JSON body for POST, PUT and PATCH, and no body for other methods:
+//
+//	contenttype.AllowContentType(nil, contenttype.WithRules(
+//	    contenttype.Rule{Methods: []string{"POST", "PUT", "PATCH"}, ContentTypes: []string{"application/json", "application/*+json"}},
+//	    contenttype.Rule{EmptyBody: contenttype.EmptyBodyAllow},
+//	))
 func AllowContentType(contentTypes []string, opts ...Option) middleware.MiddlewareFunc {
 	// Default configuration
 	config := Config{
 		allowedTypes:     contentTypes,
+		emptyBody:        EmptyBodyCheck,
 		errorBody:        defaultErrorBody,
 		errorContentType: defaultErrorContentType,
 	}
@@ -61,12 +138,12 @@ func AllowContentType(contentTypes []string, opts ...Option) middleware.Middlewa
 		opt(&config)
 	}
 
-	// Convert allowed Content-Types to lowercase and store in a map
-	allowedMap := make(map[string]struct{}, len(config.allowedTypes))
-	for _, ct := range config.allowedTypes {
-		mediaType, _, err := mime.ParseMediaType(strings.ToLower(ct))
-		if err == nil {
-			allowedMap[mediaType] = struct{}{}
+	global := compiledRule{patterns: parsePatterns(config.allowedTypes), emptyBody: config.emptyBody}
+	rules := make([]compiledRule, len(config.rules))
+	for i, r := range config.rules {
+		rules[i] = compiledRule{methods: r.Methods, patterns: parsePatterns(r.ContentTypes), emptyBody: r.EmptyBody}
+		if rules[i].emptyBody == EmptyBodyInherit {
+			rules[i].emptyBody = config.emptyBody
 		}
 	}
 
@@ -79,26 +156,43 @@ func AllowContentType(contentTypes []string, opts ...Option) middleware.Middlewa
 
 	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
 		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-			contentTypeHeader := request.Headers[http.CanonicalHeaderKey("Content-Type")]
+			rule := &global
+			for i := range rules {
+				if route.Method(rules[i].methods, request.HTTPMethod) {
+					rule = &rules[i]
+					break
+				}
+			}
+
+			if request.Body == "" && rule.emptyBody == EmptyBodyAllow {
+				return next(ctx, request)
+			}
+
+			contentTypeHeader := header.Request(&request).Get("Content-Type")
 
 			if contentTypeHeader == "" {
 				// One could consider allowing requests without Content-Type (like GET), but
 				// chi's AllowContentType also rejects requests without headers, so we follow that approach.
+				// Use WithEmptyBody(EmptyBodyAllow) to accept requests without a body.
 				return errorResponse, nil
 			}
 
-			mediaType, _, err := mime.ParseMediaType(strings.ToLower(contentTypeHeader))
+			mediaType, params, err := mime.ParseMediaType(strings.ToLower(contentTypeHeader))
 			if err != nil {
 				// Also reject if parsing fails
 				return errorResponse, nil
 			}
 
-			if _, ok := allowedMap[mediaType]; !ok {
-				// If more detailed error messages are needed, they can be set here
-				// errorResponse.Body = fmt.Sprintf("%s: Content-Type '%s' not allowed. Allowed: %v", config.ErrorBody, mediaType, config.AllowedTypes)
+			if !matchAny(rule.patterns, mediaType, params) {
 				return errorResponse, nil
 			}
 
+			if config.sniff && request.Body != "" {
+				if !sniff(mediaType, request.Body, request.IsBase64Encoded) {
+					return errorResponse, nil
+				}
+			}
+
 			return next(ctx, request)
 		}
 	}
@@ -2,6 +2,7 @@ package contenttype
 
 import (
 	"context"
+	"encoding/base64"
 	"net/http"
 	"testing"
 
@@ -218,3 +219,146 @@ func TestAllowContentType_DefaultOptions(t *testing.T) {
 	// Check default error body
 	assert.Equal(defaultErrorBody, response.Body)
 }
+
+func TestAllowContentType_HeaderCase(t *testing.T) {
+	tests := []struct {
+		name    string
+		request events.APIGatewayProxyRequest
+	}{
+		{
+			name:    "Lowercase header name (HTTP/2, HTTP API)",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"content-type": "application/json"}},
+		},
+		{
+			name:    "MultiValueHeaders only",
+			request: events.APIGatewayProxyRequest{MultiValueHeaders: map[string][]string{"content-type": {"application/json"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := AllowContentType([]string{"application/json"})(mockNextHandler)
+			response, err := handler(context.Background(), tt.request)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		})
+	}
+}
+
+func TestAllowContentType_Patterns(t *testing.T) {
+	handler := AllowContentType([]string{"application/*+json", "text/*", "application/json; charset=utf-8"})(mockNextHandler)
+	tests := []struct {
+		contentType    string
+		expectedStatus int
+	}{
+		{contentType: "application/vnd.api+json", expectedStatus: http.StatusOK},
+		{contentType: "text/csv", expectedStatus: http.StatusOK},
+		{contentType: "application/json; charset=utf-8", expectedStatus: http.StatusOK},
+		{contentType: "application/json", expectedStatus: http.StatusUnsupportedMediaType},
+		{contentType: "application/xml", expectedStatus: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			response, err := handler(context.Background(), createRequest(tt.contentType))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, response.StatusCode)
+		})
+	}
+}
+
+func TestAllowContentType_Rules(t *testing.T) {
+	handler := AllowContentType(nil, WithRules(
+		Rule{Methods: []string{"POST", "PUT", "PATCH"}, ContentTypes: []string{"application/json"}},
+		Rule{Methods: []string{"GET", "HEAD", "DELETE", "OPTIONS"}, EmptyBody: EmptyBodyAllow},
+	))(mockNextHandler)
+
+	tests := []struct {
+		name           string
+		method         string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "POST with JSON", method: http.MethodPost, contentType: "application/json", body: "{}", expectedStatus: http.StatusOK},
+		{name: "POST with XML", method: http.MethodPost, contentType: "application/xml", body: "<a/>", expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "POST without body", method: http.MethodPost, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "GET without body", method: http.MethodGet, expectedStatus: http.StatusOK},
+		{name: "delete without body (method case)", method: "delete", expectedStatus: http.StatusOK},
+		{name: "GET with body", method: http.MethodGet, contentType: "application/json", body: "{}", expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "no matching rule uses the allowlist", method: "TRACE", expectedStatus: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := createRequest(tt.contentType)
+			request.HTTPMethod = tt.method
+			request.Body = tt.body
+			response, err := handler(context.Background(), request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, response.StatusCode)
+		})
+	}
+}
+
+func TestAllowContentType_EmptyBody(t *testing.T) {
+	assert := assert.New(t)
+	handler := AllowContentType([]string{"application/json"}, WithEmptyBody(EmptyBodyAllow), WithRules(
+		Rule{Methods: []string{"POST"}, ContentTypes: []string{"application/json"}, EmptyBody: EmptyBodyCheck},
+		Rule{Methods: []string{"PUT"}, ContentTypes: []string{"application/json"}},
+	))(mockNextHandler)
+
+	request := createRequest("")
+	request.HTTPMethod = http.MethodGet
+	response, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// A rule can override the config-level policy
+	request.HTTPMethod = http.MethodPost
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusUnsupportedMediaType, response.StatusCode)
+
+	// ...or inherit it
+	request.HTTPMethod = http.MethodPut
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// A body still needs an allowed Content-Type
+	request = createRequest("text/plain")
+	request.Body = "hello"
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusUnsupportedMediaType, response.StatusCode)
+}
+
+func TestAllowContentType_WithSniff(t *testing.T) {
+	handler := AllowContentType([]string{"image/*", "application/pdf"}, WithSniff(true))(mockNextHandler)
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "png", contentType: "image/png", body: pngBody, expectedStatus: http.StatusOK},
+		{name: "pdf", contentType: "application/pdf", body: pdfBody, expectedStatus: http.StatusOK},
+		{name: "spoofed png", contentType: "image/png", body: exeBody, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "spoofed pdf", contentType: "application/pdf", body: pngBody, expectedStatus: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := createRequest(tt.contentType)
+			request.Body = base64.StdEncoding.EncodeToString([]byte(tt.body))
+			request.IsBase64Encoded = true
+			response, err := handler(context.Background(), request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, response.StatusCode)
+		})
+	}
+
+	// Without WithSniff, only the declared Content-Type is checked
+	request := createRequest("image/png")
+	request.Body = exeBody
+	response, err := AllowContentType([]string{"image/png"})(mockNextHandler)(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
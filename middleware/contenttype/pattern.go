@@ -0,0 +1,71 @@
+package contenttype
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/accept"
+)
+
+// pattern is a parsed media type pattern of an allowlist.
+type pattern struct {
+	// mediaType is the media range, such as "application/json", "text/*", "*/*" or "application/*+json".
+	mediaType string
+	// params are the parameters required in the Content-Type, with lowercase names and values.
+	params map[string]string
+}
+
+// parsePattern parses a media type pattern such as "application/json", "text/*", "application/*+json"
+// or "application/json; charset=utf-8". The second return value is false if the pattern is invalid.
+func parsePattern(s string) (pattern, bool) {
+	mediaType, params, err := mime.ParseMediaType(strings.ToLower(s))
+	if err != nil {
+		return pattern{}, false
+	}
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+		return pattern{}, false
+	}
+	// The only wildcards allowed in the subtype are "*" and a leading "*+" before a suffix
+	if strings.Contains(subtype[1:], "*") {
+		return pattern{}, false
+	}
+	if strings.HasPrefix(subtype, "*") && subtype != "*" && !strings.HasPrefix(subtype, "*+") {
+		return pattern{}, false
+	}
+	return pattern{mediaType: mediaType, params: params}, true
+}
+
+// parsePatterns parses patterns, skipping invalid ones.
+func parsePatterns(list []string) []pattern {
+	patterns := make([]pattern, 0, len(list))
+	for _, s := range list {
+		if p, ok := parsePattern(s); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// match reports whether the lowercase media type and parameters of a Content-Type match p.
+func (p pattern) match(mediaType string, params map[string]string) bool {
+	if !accept.MatchMediaType(p.mediaType, mediaType) {
+		return false
+	}
+	for k, v := range p.params {
+		if !strings.EqualFold(params[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether the media type and parameters match at least one of patterns.
+func matchAny(patterns []pattern, mediaType string, params map[string]string) bool {
+	for _, p := range patterns {
+		if p.match(mediaType, params) {
+			return true
+		}
+	}
+	return false
+}
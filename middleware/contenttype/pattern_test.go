@@ -0,0 +1,68 @@
+package contenttype
+
+import (
+	"mime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    pattern
+		ok      bool
+	}{
+		{pattern: "application/json", want: pattern{mediaType: "application/json", params: map[string]string{}}, ok: true},
+		{pattern: "Text/*", want: pattern{mediaType: "text/*", params: map[string]string{}}, ok: true},
+		{pattern: "*/*", want: pattern{mediaType: "*/*", params: map[string]string{}}, ok: true},
+		{pattern: "application/*+json", want: pattern{mediaType: "application/*+json", params: map[string]string{}}, ok: true},
+		{pattern: "application/json; Charset=UTF-8", want: pattern{mediaType: "application/json", params: map[string]string{"charset": "utf-8"}}, ok: true},
+		{pattern: "*/json", ok: false},
+		{pattern: "application/vnd.*+json", ok: false},
+		{pattern: "application/*json", ok: false},
+		{pattern: "application", ok: false},
+		{pattern: "invalid type", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, ok := parsePattern(tt.pattern)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		contentType string
+		want        bool
+	}{
+		{pattern: "application/json", contentType: "application/json", want: true},
+		{pattern: "application/json", contentType: "application/json; charset=utf-8", want: true},
+		{pattern: "application/json", contentType: "application/xml", want: false},
+		{pattern: "text/*", contentType: "text/csv", want: true},
+		{pattern: "text/*", contentType: "application/csv", want: false},
+		{pattern: "*/*", contentType: "image/png", want: true},
+		{pattern: "application/*+json", contentType: "application/vnd.api+json", want: true},
+		{pattern: "application/*+json", contentType: "application/problem+json", want: true},
+		{pattern: "application/*+json", contentType: "application/json", want: false},
+		{pattern: "application/*+json", contentType: "application/+json", want: false},
+		{pattern: "application/*+json", contentType: "text/vnd.api+json", want: false},
+		{pattern: "application/json; charset=utf-8", contentType: "application/json; charset=UTF-8", want: true},
+		{pattern: "application/json; charset=utf-8", contentType: "application/json", want: false},
+		{pattern: "application/json; charset=utf-8", contentType: "application/json; charset=iso-8859-1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.contentType, func(t *testing.T) {
+			p, ok := parsePattern(tt.pattern)
+			assert.True(t, ok)
+			mediaType, params, err := mime.ParseMediaType(tt.contentType)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, p.match(mediaType, params))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package contenttype
+
+import (
+	"encoding/base64"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// sniff reports whether the body of a request is consistent with its declared media type.
+//
+// The actual type of the body is detected from its content. The body is consistent if the detected type is
+// the declared type or a subtype of it (e.g. a DOCX file declared as application/zip), or if the detected type
+// is a less specific supertype of the declared type (e.g. text/plain for a body declared as text/csv).
+// Bodies whose declared type cannot be detected (e.g. application/x-www-form-urlencoded) are always consistent.
+// A body that is not valid base64 despite isBase64Encoded is not consistent.
+func sniff(mediaType string, body string, isBase64Encoded bool) bool {
+	declared := mimetype.Lookup(mediaType)
+	if declared == nil {
+		return true
+	}
+
+	data := []byte(body)
+	if isBase64Encoded {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return false
+		}
+	}
+	detected := mimetype.Detect(data)
+
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(mediaType) {
+			return true
+		}
+	}
+	// The root type (application/octet-stream) means the content was not recognized,
+	// which is not consistent with a type that can be detected
+	if detected.Parent() == nil {
+		return false
+	}
+	for m := declared.Parent(); m != nil; m = m.Parent() {
+		if detected.Is(m.String()) {
+			return true
+		}
+	}
+	return false
+}
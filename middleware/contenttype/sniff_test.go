@@ -0,0 +1,47 @@
+package contenttype
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Minimal file signatures used as test bodies
+var (
+	pngBody = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89"
+	pdfBody = "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n"
+	exeBody = "MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		body      string
+		want      bool
+	}{
+		{name: "png", mediaType: "image/png", body: pngBody, want: true},
+		{name: "pdf", mediaType: "application/pdf", body: pdfBody, want: true},
+		{name: "pdf declared as png", mediaType: "image/png", body: pdfBody, want: false},
+		{name: "executable declared as png", mediaType: "image/png", body: exeBody, want: false},
+		{name: "text declared as png", mediaType: "image/png", body: "hello", want: false},
+		{name: "unknown bytes declared as png", mediaType: "image/png", body: "\x00\x01\x02\x03\xfe\xff", want: false},
+		{name: "json", mediaType: "application/json", body: `{"a":1}`, want: true},
+		{name: "json declared as text", mediaType: "text/plain", body: `{"a":1}`, want: true},
+		{name: "plain text declared as csv", mediaType: "text/csv", body: "name\nalice\n", want: true},
+		{name: "png declared as json", mediaType: "application/json", body: pngBody, want: false},
+		{name: "octet-stream accepts anything", mediaType: "application/octet-stream", body: pngBody, want: true},
+		{name: "undetectable declared type", mediaType: "application/x-www-form-urlencoded", body: "a=1&b=2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sniff(tt.mediaType, tt.body, false))
+			assert.Equal(t, tt.want, sniff(tt.mediaType, base64.StdEncoding.EncodeToString([]byte(tt.body)), true))
+		})
+	}
+}
+
+func TestSniff_InvalidBase64(t *testing.T) {
+	assert.False(t, sniff("image/png", "not base64!", true))
+}
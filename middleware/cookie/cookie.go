@@ -0,0 +1,52 @@
+package cookie
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+// Parse returns the cookies sent with the request.
+//
+// Cookies are read from every Cookie header in MultiValueHeaders, or from Headers if there is none,
+// ignoring the case of the header name. Malformed cookies are skipped.
+func Parse(request *events.APIGatewayProxyRequest) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, line := range header.Request(request).Values("Cookie") {
+		for part := range strings.SplitSeq(line, ";") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			parsed, err := http.ParseCookie(part)
+			if err != nil {
+				continue
+			}
+			cookies = append(cookies, parsed...)
+		}
+	}
+	return cookies
+}
+
+// Get returns the first request cookie with the given name, or http.ErrNoCookie if there is none.
+func Get(request *events.APIGatewayProxyRequest, name string) (*http.Cookie, error) {
+	for _, c := range Parse(request) {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return nil, http.ErrNoCookie
+}
+
+// Set appends a Set-Cookie header for c to the response. Invalid cookies are silently dropped.
+//
+// Set-Cookie headers must be sent in MultiValueHeaders, since a response can only have one value per key
+// in Headers. Cookies already set by the handler or other middleware are kept: a Set-Cookie header found
+// in Headers is moved to MultiValueHeaders before c is appended.
+func Set(response *events.APIGatewayProxyResponse, c *http.Cookie) {
+	if v := c.String(); v != "" {
+		header.Add(response, "Set-Cookie", v)
+	}
+}
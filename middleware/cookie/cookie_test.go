@@ -0,0 +1,88 @@
+package cookie
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		request events.APIGatewayProxyRequest
+		want    map[string]string
+	}{
+		{
+			name:    "no cookie",
+			request: events.APIGatewayProxyRequest{},
+			want:    map[string]string{},
+		},
+		{
+			name:    "single header",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"cookie": "a=1; b=2"}},
+			want:    map[string]string{"a": "1", "b": "2"},
+		},
+		{
+			name: "multi value headers take precedence",
+			request: events.APIGatewayProxyRequest{
+				Headers:           map[string]string{"Cookie": "b=2"},
+				MultiValueHeaders: map[string][]string{"Cookie": {"a=1", "b=2; c=3"}},
+			},
+			want: map[string]string{"a": "1", "b": "2", "c": "3"},
+		},
+		{
+			name:    "malformed cookies are skipped",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": "a=1; bad name=x; ;b=\"2\""}},
+			want:    map[string]string{"a": "1", "b": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for _, c := range Parse(&tt.request) {
+				got[c.Name] = c.Value
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	request := events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": "a=1; session=abc; session=def"}}
+
+	c, err := Get(&request, "session")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", c.Value)
+
+	_, err = Get(&request, "missing")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+}
+
+func TestSet(t *testing.T) {
+	assert := assert.New(t)
+	response := events.APIGatewayProxyResponse{
+		Headers: map[string]string{"Content-Type": "text/plain", "set-cookie": "theme=dark"},
+	}
+
+	Set(&response, &http.Cookie{Name: "a", Value: "1", Path: "/", HttpOnly: true})
+	Set(&response, &http.Cookie{Name: "b", Value: "2"})
+
+	assert.Equal([]string{"theme=dark", "a=1; Path=/; HttpOnly", "b=2"}, response.MultiValueHeaders["Set-Cookie"])
+	assert.Equal(map[string]string{"Content-Type": "text/plain"}, response.Headers)
+}
+
+func TestSet_ExistingMultiValueKey(t *testing.T) {
+	response := events.APIGatewayProxyResponse{
+		MultiValueHeaders: map[string][]string{"set-cookie": {"theme=dark"}},
+	}
+	Set(&response, &http.Cookie{Name: "a", Value: "1"})
+	assert.Equal(t, map[string][]string{"set-cookie": {"theme=dark", "a=1"}}, response.MultiValueHeaders)
+}
+
+func TestSet_Invalid(t *testing.T) {
+	response := events.APIGatewayProxyResponse{}
+	Set(&response, &http.Cookie{Name: "bad name", Value: "1"})
+	assert.Nil(t, response.MultiValueHeaders)
+}
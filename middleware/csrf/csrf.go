@@ -0,0 +1,354 @@
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/cookie"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultHeader is the default request header carrying the token.
+	defaultHeader = "X-CSRF-Token"
+
+	// defaultFormField is the default form field carrying the token.
+	defaultFormField = "csrf_token"
+
+	// defaultErrorBody is the default response body when the request is rejected.
+	defaultErrorBody = "Forbidden"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the key type used to store the token within the context. Use Token or TemplateField to read it.
+type CtxKey struct{}
+
+// tokenInfo is the value stored in the context.
+type tokenInfo struct {
+	token     string
+	formField string
+}
+
+// Token returns the CSRF token of the request, to be embedded in pages and sent back by forms or scripts.
+// It returns "" if the CSRF middleware did not run.
+func Token(ctx context.Context) string {
+	info, _ := ctx.Value(CtxKey{}).(tokenInfo)
+	return info.token
+}
+
+// TemplateField returns a hidden form input carrying the CSRF token, for use in html/template.
+func TemplateField(ctx context.Context) template.HTML {
+	info, ok := ctx.Value(CtxKey{}).(tokenInfo)
+	if !ok {
+		return ""
+	}
+	return template.HTML(fmt.Sprintf(`<input type="hidden" name="%s" value="%s">`,
+		template.HTMLEscapeString(info.formField), template.HTMLEscapeString(info.token)))
+}
+
+// SessionIDFunc returns the session ID of a request, or "" if the request has no session.
+type SessionIDFunc func(ctx context.Context, request events.APIGatewayProxyRequest) string
+
+// Config is the configuration for the CSRF middleware.
+type Config struct {
+	cookie           http.Cookie
+	secret           []byte
+	store            TokenStore
+	sessionID        SessionIDFunc
+	header           string
+	formField        string
+	trustedOrigins   []string
+	allowSameSite    bool
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the CSRF configuration.
+type Option func(*Config)
+
+// WithCookie sets the cookie holding the token in the double-submit cookie pattern. Its Name, Path, Domain,
+// MaxAge, Secure, HttpOnly and SameSite fields are used. The default is a session cookie named "__Host-csrf"
+// with Path=/, Secure, HttpOnly and SameSite=Lax.
+func WithCookie(cookie http.Cookie) Option {
+	return func(c *Config) {
+		c.cookie = cookie
+	}
+}
+
+// WithSecret signs the token of the double-submit cookie with HMAC-SHA256, so that a token planted by
+// a sibling subdomain is rejected. CSRF panics if secret is empty.
+func WithSecret(secret []byte) Option {
+	return func(c *Config) {
+		if len(secret) == 0 {
+			panic(errors.New("csrf: empty secret"))
+		}
+		c.secret = secret
+	}
+}
+
+// WithSynchronizer switches to the synchronizer token pattern: the token is kept in store for the session
+// returned by sessionID, instead of in a cookie. Unsafe requests without a session are rejected.
+func WithSynchronizer(store TokenStore, sessionID SessionIDFunc) Option {
+	return func(c *Config) {
+		c.store = store
+		c.sessionID = sessionID
+	}
+}
+
+// WithHeader specifies the request header carrying the token. The default is "X-CSRF-Token".
+func WithHeader(name string) Option {
+	return func(c *Config) {
+		c.header = name
+	}
+}
+
+// WithFormField specifies the form field carrying the token in application/x-www-form-urlencoded requests.
+// The default is "csrf_token".
+func WithFormField(name string) Option {
+	return func(c *Config) {
+		c.formField = name
+	}
+}
+
+// WithTrustedOrigins allows unsafe requests from the given cross-site origins (e.g. "https://admin.example.com").
+func WithTrustedOrigins(origins ...string) Option {
+	return func(c *Config) {
+		c.trustedOrigins = append(c.trustedOrigins, origins...)
+	}
+}
+
+// WithAllowSameSite allows unsafe requests whose Sec-Fetch-Site header is "same-site", i.e. from sibling subdomains.
+// By default only "same-origin" requests are allowed.
+func WithAllowSameSite(allow bool) Option {
+	return func(c *Config) {
+		c.allowSameSite = allow
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when the request is rejected.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// CSRF creates middleware protecting cookie-authenticated endpoints against cross-site request forgery.
+//
+// Safe methods (GET, HEAD, OPTIONS and TRACE) are always passed through. Unsafe requests must:
+//   - come from the same origin: the Sec-Fetch-Site header, if present, must be "same-origin" (or "same-site"
+//     with WithAllowSameSite), and the Origin header, if present, must match the Host header,
+//     unless the origin is trusted with WithTrustedOrigins;
+//   - carry the token in the X-CSRF-Token header or the "csrf_token" form field, matching the token
+//     of the double-submit cookie or, with WithSynchronizer, the token stored for the session.
+//
+// Otherwise, the middleware returns 403 Forbidden. A token is issued on the first request and made available
+// to the handler with Token and TemplateField; in the double-submit pattern it is sent in a Set-Cookie header.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, csrf.CSRF(csrf.WithSecret(secret)))
+//
+//	// In the handler
+//	tmpl.Execute(w, map[string]any{"CSRFField": csrf.TemplateField(ctx)})
+func CSRF(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		cookie: http.Cookie{
+			Name:     "__Host-csrf",
+			Path:     "/",
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		header:           defaultHeader,
+		formField:        defaultFormField,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusForbidden,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			safe := isSafeMethod(request.HTTPMethod)
+			if !safe && !config.sameOrigin(&request) {
+				return errorResponse, nil
+			}
+
+			// Find the current token
+			var token, sessionID string
+			if config.store != nil {
+				sessionID = config.sessionID(ctx, request)
+				if sessionID == "" {
+					if !safe {
+						return errorResponse, nil
+					}
+					return next(ctx, request)
+				}
+				stored, ok, err := config.store.Load(ctx, sessionID)
+				if err != nil {
+					return events.APIGatewayProxyResponse{}, fmt.Errorf("csrf: %w", err)
+				}
+				if ok {
+					token = stored
+				}
+			} else {
+				token = config.cookieToken(&request)
+			}
+
+			if !safe {
+				given := config.requestToken(&request)
+				if token == "" || given == "" || subtle.ConstantTimeCompare([]byte(token), []byte(given)) != 1 {
+					return errorResponse, nil
+				}
+			}
+
+			// Issue a new token on the first request
+			issued := false
+			if token == "" {
+				token = config.newToken()
+				issued = true
+				if config.store != nil {
+					if err := config.store.Save(ctx, sessionID, token); err != nil {
+						return events.APIGatewayProxyResponse{}, fmt.Errorf("csrf: %w", err)
+					}
+				}
+			}
+
+			ctxWithToken := context.WithValue(ctx, CtxKey{}, tokenInfo{token: token, formField: config.formField})
+			response, err := next(ctxWithToken, request)
+			if err != nil {
+				return response, err
+			}
+			if issued && config.store == nil {
+				c := config.cookie
+				c.Value = token
+				cookie.Set(&response, &c)
+			}
+			return response, nil
+		}
+	}
+}
+
+// isSafeMethod reports whether method is safe (RFC 9110 Section 9.2.1).
+func isSafeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// sameOrigin checks the Sec-Fetch-Site and Origin headers of an unsafe request.
+func (c *Config) sameOrigin(request *events.APIGatewayProxyRequest) bool {
+	origin := header.Request(request).Get("Origin")
+	trusted := origin != "" && slices.ContainsFunc(c.trustedOrigins, func(o string) bool {
+		return strings.EqualFold(o, origin)
+	})
+
+	switch site := strings.ToLower(header.Request(request).Get("Sec-Fetch-Site")); site {
+	case "", "same-origin", "none":
+	case "same-site":
+		if !c.allowSameSite && !trusted {
+			return false
+		}
+	default:
+		if !trusted {
+			return false
+		}
+	}
+
+	if origin == "" || trusted {
+		return true
+	}
+	host := header.Request(request).Get("Host")
+	if host == "" {
+		host = request.RequestContext.DomainName
+	}
+	return strings.EqualFold(origin, "https://"+host)
+}
+
+// cookieToken returns the token of the double-submit cookie, or "" if it is absent or its signature is invalid.
+func (c *Config) cookieToken(request *events.APIGatewayProxyRequest) string {
+	ck, err := cookie.Get(request, c.cookie.Name)
+	if err != nil {
+		return ""
+	}
+	token := ck.Value
+	if token == "" || c.secret == nil {
+		return token
+	}
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(c.sign(value))) {
+		return ""
+	}
+	return token
+}
+
+// requestToken returns the token sent with the request in the header or the form field.
+func (c *Config) requestToken(request *events.APIGatewayProxyRequest) string {
+	if token := header.Request(request).Get(c.header); token != "" {
+		return token
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Request(request).Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return ""
+		}
+		body = string(decoded)
+	}
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return ""
+	}
+	return form.Get(c.formField)
+}
+
+// newToken returns a new random token, signed if a secret is configured.
+func (c *Config) newToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if c.secret != nil && c.store == nil {
+		token += "." + c.sign(token)
+	}
+	return token
+}
+
+// sign returns the HMAC-SHA256 signature of value.
+func (c *Config) sign(value string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
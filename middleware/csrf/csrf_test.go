@@ -0,0 +1,268 @@
+package csrf
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/stretchr/testify/assert"
+)
+
+// okHandler is a handler that records the token and returns 200 OK.
+func okHandler(token *string) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		*token = Token(ctx)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}
+}
+
+// createRequest creates a request to api.example.com with the given method and headers.
+func createRequest(method string, headers map[string]string) events.APIGatewayProxyRequest {
+	h := map[string]string{"Host": "api.example.com"}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return events.APIGatewayProxyRequest{HTTPMethod: method, Headers: h}
+}
+
+// issueToken performs a GET request and returns the token set in the cookie.
+func issueToken(t *testing.T, handler func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)) string {
+	response, err := handler(context.Background(), createRequest(http.MethodGet, nil))
+	assert.NoError(t, err)
+	cookies := response.MultiValueHeaders["Set-Cookie"]
+	if !assert.Len(t, cookies, 1) {
+		t.FailNow()
+	}
+	cookie, err := http.ParseSetCookie(cookies[0])
+	assert.NoError(t, err)
+	return cookie.Value
+}
+
+func TestCSRF_DoubleSubmit(t *testing.T) {
+	assert := assert.New(t)
+	var token string
+	handler := CSRF()(okHandler(&token))
+
+	// A token is issued on the first request
+	response, err := handler(context.Background(), createRequest(http.MethodGet, nil))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.NotEmpty(token)
+	assert.Equal([]string{"__Host-csrf=" + token + "; Path=/; HttpOnly; Secure; SameSite=Lax"}, response.MultiValueHeaders["Set-Cookie"])
+
+	cookie := "__Host-csrf=" + token
+	form := url.Values{"csrf_token": {token}, "name": {"x"}}.Encode()
+
+	tests := []struct {
+		name     string
+		request  events.APIGatewayProxyRequest
+		expected int
+	}{
+		{"Safe method without token", createRequest(http.MethodGet, nil), http.StatusOK},
+		{"Token in header", createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "X-CSRF-Token": token}), http.StatusOK},
+		{"Token in form", func() events.APIGatewayProxyRequest {
+			r := createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "Content-Type": "application/x-www-form-urlencoded"})
+			r.Body = form
+			return r
+		}(), http.StatusOK},
+		{"Token in base64 form", func() events.APIGatewayProxyRequest {
+			r := createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "Content-Type": "application/x-www-form-urlencoded; charset=utf-8"})
+			r.Body = base64.StdEncoding.EncodeToString([]byte(form))
+			r.IsBase64Encoded = true
+			return r
+		}(), http.StatusOK},
+		{"Form field ignored for JSON", func() events.APIGatewayProxyRequest {
+			r := createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "Content-Type": "application/json"})
+			r.Body = form
+			return r
+		}(), http.StatusForbidden},
+		{"Missing token", createRequest(http.MethodPost, map[string]string{"Cookie": cookie}), http.StatusForbidden},
+		{"Missing cookie", createRequest(http.MethodPost, map[string]string{"X-CSRF-Token": token}), http.StatusForbidden},
+		{"Token mismatch", createRequest(http.MethodDelete, map[string]string{"Cookie": cookie, "X-CSRF-Token": token + "x"}), http.StatusForbidden},
+		{"Same origin", createRequest(http.MethodPut, map[string]string{"Cookie": cookie, "X-CSRF-Token": token, "Origin": "https://api.example.com", "Sec-Fetch-Site": "same-origin"}), http.StatusOK},
+		{"Cross-site origin", createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "X-CSRF-Token": token, "Origin": "https://evil.example"}), http.StatusForbidden},
+		{"Null origin", createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "X-CSRF-Token": token, "Origin": "null"}), http.StatusForbidden},
+		{"Cross-site fetch", createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "X-CSRF-Token": token, "Sec-Fetch-Site": "cross-site"}), http.StatusForbidden},
+		{"Same-site fetch", createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "X-CSRF-Token": token, "Sec-Fetch-Site": "same-site"}), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := handler(context.Background(), tt.request)
+			assert.NoError(err)
+			assert.Equal(tt.expected, response.StatusCode)
+			if tt.expected == http.StatusForbidden {
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+
+	// An existing token is kept and not set again
+	response, err = handler(context.Background(), createRequest(http.MethodGet, map[string]string{"Cookie": cookie}))
+	assert.NoError(err)
+	assert.Empty(response.MultiValueHeaders["Set-Cookie"])
+	assert.Equal(strings.TrimPrefix(cookie, "__Host-csrf="), token)
+}
+
+func TestCSRF_EmptySecret(t *testing.T) {
+	assert.Panics(t, func() { CSRF(WithSecret(nil)) })
+	assert.Panics(t, func() { CSRF(WithSecret([]byte{})) })
+}
+
+func TestCSRF_Secret(t *testing.T) {
+	assert := assert.New(t)
+	var token string
+	handler := CSRF(WithSecret([]byte("secret")))(okHandler(&token))
+
+	issued := issueToken(t, handler)
+	assert.Contains(issued, ".")
+
+	response, err := handler(context.Background(), createRequest(http.MethodPost, map[string]string{"Cookie": "__Host-csrf=" + issued, "X-CSRF-Token": issued}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// A token planted without the secret is rejected
+	for _, planted := range []string{"attacker-token", "attacker.token"} {
+		response, err = handler(context.Background(), createRequest(http.MethodPost, map[string]string{"Cookie": "__Host-csrf=" + planted, "X-CSRF-Token": planted}))
+		assert.NoError(err)
+		assert.Equal(http.StatusForbidden, response.StatusCode)
+	}
+
+	// A planted cookie is replaced on safe requests
+	response, err = handler(context.Background(), createRequest(http.MethodGet, map[string]string{"Cookie": "__Host-csrf=attacker-token"}))
+	assert.NoError(err)
+	assert.Len(response.MultiValueHeaders["Set-Cookie"], 1)
+	assert.NotEqual("attacker-token", token)
+}
+
+func TestCSRF_Synchronizer(t *testing.T) {
+	assert := assert.New(t)
+	var token string
+	store := NewMemoryTokenStore()
+	sessionID := func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		return header.Request(&request).Get("X-Session")
+	}
+	handler := CSRF(WithSynchronizer(store, sessionID))(okHandler(&token))
+
+	// The token is stored for the session, and no cookie is set
+	response, err := handler(context.Background(), createRequest(http.MethodGet, map[string]string{"X-Session": "s1"}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Empty(response.MultiValueHeaders)
+	stored, ok, _ := store.Load(context.Background(), "s1")
+	assert.True(ok)
+	assert.Equal(token, stored)
+
+	response, err = handler(context.Background(), createRequest(http.MethodPost, map[string]string{"X-Session": "s1", "X-CSRF-Token": stored}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// The token of another session is rejected
+	_, _ = handler(context.Background(), createRequest(http.MethodGet, map[string]string{"X-Session": "s2"}))
+	response, err = handler(context.Background(), createRequest(http.MethodPost, map[string]string{"X-Session": "s2", "X-CSRF-Token": stored}))
+	assert.NoError(err)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+
+	// Unsafe requests without a session are rejected, safe ones get no token
+	response, err = handler(context.Background(), createRequest(http.MethodPost, map[string]string{"X-CSRF-Token": stored}))
+	assert.NoError(err)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+	response, err = handler(context.Background(), createRequest(http.MethodGet, nil))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Empty(token)
+}
+
+// failingStore is a TokenStore that always fails.
+type failingStore struct{ err error }
+
+func (s failingStore) Load(ctx context.Context, sessionID string) (string, bool, error) {
+	return "", false, s.err
+}
+
+func (s failingStore) Save(ctx context.Context, sessionID, token string) error {
+	return s.err
+}
+
+func TestCSRF_StoreError(t *testing.T) {
+	storeErr := errors.New("store unavailable")
+	var token string
+	handler := CSRF(WithSynchronizer(failingStore{storeErr}, func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		return "s1"
+	}))(okHandler(&token))
+
+	_, err := handler(context.Background(), createRequest(http.MethodGet, nil))
+	assert.ErrorIs(t, err, storeErr)
+}
+
+func TestCSRF_Options(t *testing.T) {
+	assert := assert.New(t)
+	var token string
+	handler := CSRF(
+		WithCookie(http.Cookie{Name: "csrf", Path: "/admin", SameSite: http.SameSiteStrictMode}),
+		WithHeader("X-XSRF-Token"),
+		WithFormField("_token"),
+		WithTrustedOrigins("https://admin.example.com"),
+		WithAllowSameSite(true),
+		WithResponse("application/json", `{"error":"csrf"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		token = Token(ctx)
+		assert.Equal(`<input type="hidden" name="_token" value="`+token+`">`, string(TemplateField(ctx)))
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Set-Cookie": "session=abc"},
+		}, nil
+	})
+
+	// Existing Set-Cookie headers are kept
+	response, err := handler(context.Background(), createRequest(http.MethodGet, nil))
+	assert.NoError(err)
+	assert.Equal([]string{"session=abc", "csrf=" + token + "; Path=/admin; SameSite=Strict"}, response.MultiValueHeaders["Set-Cookie"])
+	assert.Empty(response.Headers["Set-Cookie"])
+
+	cookie := "csrf=" + token
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected int
+	}{
+		{"Custom header", map[string]string{"Cookie": cookie, "X-XSRF-Token": token}, http.StatusOK},
+		{"Default header ignored", map[string]string{"Cookie": cookie, "X-CSRF-Token": token}, http.StatusForbidden},
+		{"Trusted origin", map[string]string{"Cookie": cookie, "X-XSRF-Token": token, "Origin": "https://admin.example.com", "Sec-Fetch-Site": "cross-site"}, http.StatusOK},
+		{"Same-site allowed", map[string]string{"Cookie": cookie, "X-XSRF-Token": token, "Sec-Fetch-Site": "same-site"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := handler(context.Background(), createRequest(http.MethodPost, tt.headers))
+			assert.NoError(err)
+			assert.Equal(tt.expected, response.StatusCode)
+			if tt.expected == http.StatusForbidden {
+				assert.Equal(`{"error":"csrf"}`, response.Body)
+				assert.Equal("application/json", response.Headers["Content-Type"])
+			}
+		})
+	}
+
+	// Custom form field
+	request := createRequest(http.MethodPost, map[string]string{"Cookie": cookie, "Content-Type": "application/x-www-form-urlencoded"})
+	request.Body = "_token=" + url.QueryEscape(token)
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func TestTokenHelpers(t *testing.T) {
+	assert.Empty(t, Token(context.Background()))
+	assert.Empty(t, TemplateField(context.Background()))
+
+	ctx := context.WithValue(context.Background(), CtxKey{}, tokenInfo{token: `a"b`, formField: "csrf_token"})
+	assert.Equal(t, `a"b`, Token(ctx))
+	assert.Equal(t, `<input type="hidden" name="csrf_token" value="a&#34;b">`, string(TemplateField(ctx)))
+}
@@ -0,0 +1,42 @@
+package csrf
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenStore stores synchronizer tokens on the server, keyed by session ID.
+type TokenStore interface {
+	// Load returns the token of the session. The second return value is false if there is none.
+	Load(ctx context.Context, sessionID string) (string, bool, error)
+	// Save stores the token of the session.
+	Save(ctx context.Context, sessionID, token string) error
+}
+
+// MemoryTokenStore is a TokenStore keeping tokens in memory.
+// It is only suitable for a single instance or for tests, as Lambda instances do not share memory.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]string)}
+}
+
+// Load implements TokenStore.
+func (s *MemoryTokenStore) Load(ctx context.Context, sessionID string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[sessionID]
+	return token, ok, nil
+}
+
+// Save implements TokenStore.
+func (s *MemoryTokenStore) Save(ctx context.Context, sessionID, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[sessionID] = token
+	return nil
+}
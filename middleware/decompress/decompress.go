@@ -0,0 +1,257 @@
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultMaxSize is the default maximum size (in bytes) of a decompressed request body.
+	defaultMaxSize = 10 << 20
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// errTooLarge is returned when the decompressed body exceeds the configured maximum size.
+var errTooLarge = errors.New("decompressed body too large")
+
+// Decoder decompresses a request body for a single content coding.
+type Decoder interface {
+	// NewReader returns a reader that yields the decompressed form of r.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+// DecoderFunc is an adapter to allow the use of ordinary functions as a Decoder.
+type DecoderFunc func(r io.Reader) (io.Reader, error)
+
+// NewReader calls f(r).
+func (f DecoderFunc) NewReader(r io.Reader) (io.Reader, error) {
+	return f(r)
+}
+
+// Config is the configuration for the Decompress middleware.
+type Config struct {
+	maxSize          int64
+	decoders         map[string]Decoder
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the Decompress configuration.
+type Option func(*Config)
+
+// WithMaxSize sets the maximum size (in bytes) of the decompressed request body.
+// Requests whose body would exceed it are rejected with 413 Request Entity Too Large.
+// The default is 10 MiB.
+func WithMaxSize(size int64) Option {
+	return func(c *Config) {
+		c.maxSize = size
+	}
+}
+
+// WithDecoder registers a Decoder for the given content coding (e.g. "br").
+// Registering a coding that is already known replaces its decoder.
+func WithDecoder(coding string, decoder Decoder) Option {
+	return func(c *Config) {
+		c.decoders[strings.ToLower(coding)] = decoder
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body for error cases.
+// The status code still reflects the kind of error.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// Decompress creates middleware that transparently decompresses request bodies sent with a Content-Encoding header.
+//
+// The built-in decoders support the "gzip" (and its "x-gzip" alias) and "deflate" content codings.
+// Other codings can be added with the WithDecoder option. Multiple codings listed in
+// Content-Encoding are removed in reverse order of application.
+//
+// On success the request is rewritten so that downstream middleware and handlers see the plain body:
+// the Content-Encoding and Content-Length headers are removed, and the body is stored as text
+// (IsBase64Encoded is false) if it is valid UTF-8, or base64 encoded otherwise.
+//
+// The middleware responds with:
+//   - 415 Unsupported Media Type if a content coding is not supported
+//   - 413 Request Entity Too Large if the decompressed body exceeds the maximum size (10 MiB by default)
+//   - 400 Bad Request if the body cannot be decoded
+//
+// Requests without Content-Encoding (or with "identity") are passed through unchanged.
+func Decompress(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		maxSize: defaultMaxSize,
+		decoders: map[string]Decoder{
+			"gzip":    DecoderFunc(gzipReader),
+			"x-gzip":  DecoderFunc(gzipReader),
+			"deflate": DecoderFunc(deflateReader),
+		},
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			codings := parseCodings(strings.Join(header.Request(&request).Values("Content-Encoding"), ", "))
+			if len(codings) == 0 {
+				return next(ctx, request)
+			}
+
+			decoders := make([]Decoder, len(codings))
+			for i, coding := range codings {
+				decoder, ok := config.decoders[coding]
+				if !ok {
+					return errorResponse(&config, http.StatusUnsupportedMediaType), nil
+				}
+				decoders[i] = decoder
+			}
+
+			body := []byte(request.Body)
+			if request.IsBase64Encoded {
+				decoded, err := base64.StdEncoding.DecodeString(request.Body)
+				if err != nil {
+					return errorResponse(&config, http.StatusBadRequest), nil
+				}
+				body = decoded
+			}
+
+			// Codings are listed in the order they were applied, so undo them from the last one
+			for i := len(decoders) - 1; i >= 0; i-- {
+				decoded, err := decode(decoders[i], body, config.maxSize)
+				if errors.Is(err, errTooLarge) {
+					return errorResponse(&config, http.StatusRequestEntityTooLarge), nil
+				}
+				if err != nil {
+					return errorResponse(&config, http.StatusBadRequest), nil
+				}
+				body = decoded
+			}
+
+			delHeader(&request, "Content-Encoding")
+			delHeader(&request, "Content-Length")
+			if utf8.Valid(body) {
+				request.Body = string(body)
+				request.IsBase64Encoded = false
+			} else {
+				request.Body = base64.StdEncoding.EncodeToString(body)
+				request.IsBase64Encoded = true
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// errorResponse builds the error response for the given status code.
+func errorResponse(config *Config, statusCode int) events.APIGatewayProxyResponse {
+	body := config.errorBody
+	if body == "" {
+		body = http.StatusText(statusCode)
+	}
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Body:       body,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+}
+
+// parseCodings splits a Content-Encoding header value into lowercase codings, dropping "identity".
+func parseCodings(contentEncoding string) []string {
+	var codings []string
+	for _, coding := range strings.Split(contentEncoding, ",") {
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" || coding == "identity" {
+			continue
+		}
+		codings = append(codings, coding)
+	}
+	return codings
+}
+
+// decode runs data through decoder, failing with errTooLarge once the output exceeds maxSize bytes.
+func decode(decoder Decoder, data []byte, maxSize int64) ([]byte, error) {
+	r, err := decoder.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// Read one byte past the limit to tell "exactly at the limit" from "over the limit"
+	decoded, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decoded)) > maxSize {
+		return nil, errTooLarge
+	}
+	return decoded, nil
+}
+
+// gzipReader returns a reader that decompresses the gzip coding.
+func gzipReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateReader returns a reader that decompresses the deflate coding.
+// The "deflate" content coding is defined as the zlib format, but some clients send a raw
+// deflate stream instead, so the zlib header is checked before choosing the format.
+func deflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && isZlibHeader(magic) {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// isZlibHeader reports whether the two bytes form a valid zlib (RFC 1950) header using the deflate method.
+func isZlibHeader(magic []byte) bool {
+	return magic[0]&0x0f == 8 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0
+}
+
+// delHeader removes the named request header, ignoring the case of the name.
+// The header maps are copied before modification so that the caller's request is not affected.
+func delHeader(request *events.APIGatewayProxyRequest, key string) {
+	headers := make(map[string]string, len(request.Headers))
+	for k, v := range request.Headers {
+		if !strings.EqualFold(k, key) {
+			headers[k] = v
+		}
+	}
+	request.Headers = headers
+
+	if request.MultiValueHeaders != nil {
+		multiValueHeaders := make(map[string][]string, len(request.MultiValueHeaders))
+		for k, v := range request.MultiValueHeaders {
+			if !strings.EqualFold(k, key) {
+				multiValueHeaders[k] = v
+			}
+		}
+		request.MultiValueHeaders = multiValueHeaders
+	}
+}
@@ -0,0 +1,249 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/stretchr/testify/assert"
+)
+
+// gzipData compresses data with gzip.
+func gzipData(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+// zlibData compresses data with zlib.
+func zlibData(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+// flateData compresses data as a raw deflate stream.
+func flateData(data []byte) []byte {
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+// createRequest creates a base64 encoded request with the given Content-Encoding header.
+func createRequest(contentEncoding string, body []byte) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Headers: map[string]string{
+			"Content-Type":     "application/json",
+			"Content-Encoding": contentEncoding,
+			"Content-Length":   "123",
+		},
+		Body:            base64.StdEncoding.EncodeToString(body),
+		IsBase64Encoded: true,
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	plain := []byte(`{"name":"John Doe","email":"john@example.com"}`)
+
+	tests := []struct {
+		name               string
+		request            events.APIGatewayProxyRequest
+		expectedStatusCode int
+		expectNextCalled   bool
+	}{
+		{
+			name:               "gzip",
+			request:            createRequest("gzip", gzipData(plain)),
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "x-gzip alias",
+			request:            createRequest("x-gzip", gzipData(plain)),
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "deflate (zlib)",
+			request:            createRequest("deflate", zlibData(plain)),
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "deflate (raw)",
+			request:            createRequest("deflate", flateData(plain)),
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "Multiple codings",
+			request:            createRequest("deflate, gzip", gzipData(zlibData(plain))),
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name: "Body not base64 encoded",
+			request: events.APIGatewayProxyRequest{
+				Headers: map[string]string{"content-encoding": "GZIP"},
+				Body:    string(gzipData(plain)),
+			},
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "Unsupported coding",
+			request:            createRequest("br", plain),
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+			expectNextCalled:   false,
+		},
+		{
+			name:               "Corrupt body",
+			request:            createRequest("gzip", []byte("not gzip")),
+			expectedStatusCode: http.StatusBadRequest,
+			expectNextCalled:   false,
+		},
+		{
+			name: "Invalid base64",
+			request: events.APIGatewayProxyRequest{
+				Headers:         map[string]string{"Content-Encoding": "gzip"},
+				Body:            "!!!",
+				IsBase64Encoded: true,
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectNextCalled:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			nextCalled := false
+
+			handler := Decompress()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				nextCalled = true
+				assert.Equal(string(plain), request.Body)
+				assert.False(request.IsBase64Encoded)
+				assert.Empty(header.Request(&request).Get("Content-Encoding"))
+				assert.Empty(header.Request(&request).Get("Content-Length"))
+				assert.Equal(tt.request.Headers["Content-Type"], request.Headers["Content-Type"])
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), tt.request)
+
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatusCode, response.StatusCode)
+			assert.Equal(tt.expectNextCalled, nextCalled)
+			if !tt.expectNextCalled {
+				assert.Equal(http.StatusText(tt.expectedStatusCode), response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestDecompress_NoContentEncoding(t *testing.T) {
+	assert := assert.New(t)
+	request := events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Encoding": "identity", "Content-Length": "2"},
+		Body:    "{}",
+	}
+
+	handler := Decompress()(func(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Equal(request, req)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func TestDecompress_BinaryBody(t *testing.T) {
+	assert := assert.New(t)
+	binary := []byte{0x89, 'P', 'N', 'G', 0xff, 0xfe, 0x00}
+
+	handler := Decompress()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.True(request.IsBase64Encoded)
+		decoded, err := base64.StdEncoding.DecodeString(request.Body)
+		assert.NoError(err)
+		assert.Equal(binary, decoded)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), createRequest("gzip", gzipData(binary)))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func TestDecompress_MaxSize(t *testing.T) {
+	assert := assert.New(t)
+	// Highly compressible payload, as used in zip bombs
+	bomb := gzipData(bytes.Repeat([]byte{'a'}, 1<<20))
+
+	handler := Decompress(WithMaxSize(1024))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		t.Fatal("next handler must not be called")
+		return events.APIGatewayProxyResponse{}, nil
+	})
+	response, err := handler(context.Background(), createRequest("gzip", bomb))
+	assert.NoError(err)
+	assert.Equal(http.StatusRequestEntityTooLarge, response.StatusCode)
+
+	// Exactly at the limit is allowed
+	handler = Decompress(WithMaxSize(1024))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+	response, err = handler(context.Background(), createRequest("gzip", gzipData(bytes.Repeat([]byte{'a'}, 1024))))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func TestDecompress_Options(t *testing.T) {
+	assert := assert.New(t)
+
+	// A fake "rev" coding that is undone by upper-casing the body
+	upper := DecoderFunc(func(r io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(strings.ToUpper(string(data))), nil
+	})
+
+	handler := Decompress(
+		WithDecoder("upper", upper),
+		WithResponse("application/json", `{"error":"bad encoding"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: request.Body}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Content-Encoding": "upper"},
+		Body:    "hello",
+	})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("HELLO", response.Body)
+
+	response, err = handler(context.Background(), createRequest("compress", []byte("data")))
+	assert.NoError(err)
+	assert.Equal(http.StatusUnsupportedMediaType, response.StatusCode)
+	assert.Equal(`{"error":"bad encoding"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
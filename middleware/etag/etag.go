@@ -0,0 +1,297 @@
+package etag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultErrorBody is the default response body when a precondition fails.
+	defaultErrorBody = "Precondition Failed"
+
+	// defaultErrorContentType is the default Content-Type for precondition failure responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// State describes the current state of the target resource.
+// It is used to evaluate preconditions before the handler is executed.
+type State struct {
+	// ETag is the current entity tag including the quotes (e.g. `"v1"` or `W/"v1"`), or empty if unknown.
+	ETag string
+	// LastModified is the last modification time, or the zero time if unknown.
+	LastModified time.Time
+	// Exists reports whether the resource currently exists.
+	Exists bool
+}
+
+// StateFunc returns the current state of the resource targeted by the request.
+type StateFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (State, error)
+
+// Config is the configuration for the ETag middleware.
+type Config struct {
+	weak             bool
+	stateFunc        StateFunc
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the ETag configuration.
+type Option func(*Config)
+
+// WithWeak specifies whether generated entity tags are weak (W/"...") instead of strong.
+// By default, strong entity tags are generated.
+func WithWeak(weak bool) Option {
+	return func(c *Config) {
+		c.weak = weak
+	}
+}
+
+// WithStateFunc sets a function that returns the current state of the target resource.
+// When set, preconditions are evaluated before the handler is executed, which is required
+// to protect writes with If-Match and If-Unmodified-Since, and allows conditional GET
+// requests to be answered without executing the handler.
+func WithStateFunc(fn StateFunc) Option {
+	return func(c *Config) {
+		c.stateFunc = fn
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when a precondition fails.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// outcome is the result of evaluating the request preconditions.
+type outcome int
+
+const (
+	proceed outcome = iota
+	notModified
+	preconditionFailed
+)
+
+// ETag creates middleware that adds entity tags to responses and handles conditional requests.
+//
+// For successful (200) responses to GET and HEAD requests that do not already carry an ETag header,
+// an entity tag is computed from the SHA-256 hash of the response body. Handlers may set their own
+// ETag (and Last-Modified) header instead.
+//
+// Preconditions are evaluated as described in RFC 9110:
+//   - If-Match and If-Unmodified-Since fail with 412 Precondition Failed
+//   - If-None-Match and If-Modified-Since yield 304 Not Modified with an empty body for GET and HEAD,
+//     and a matching If-None-Match fails other methods with 412 Precondition Failed
+//
+// Without the WithStateFunc option, preconditions are evaluated against the response returned by the handler,
+// which only applies to GET and HEAD requests. With WithStateFunc, they are evaluated against the
+// returned state before the handler runs, for all methods.
+//
+// When combined with Compress, place ETag outside of it so that the tag is computed from the encoded body.
+func ETag(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusPreconditionFailed,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			method := strings.ToUpper(request.HTTPMethod)
+			safe := method == http.MethodGet || method == http.MethodHead
+
+			if config.stateFunc != nil && hasPreconditions(&request) {
+				state, err := config.stateFunc(ctx, request)
+				if err != nil {
+					return events.APIGatewayProxyResponse{}, err
+				}
+				switch evaluate(&request, safe, state) {
+				case notModified:
+					response := events.APIGatewayProxyResponse{StatusCode: http.StatusNotModified}
+					if state.ETag != "" {
+						header.Set(&response, "ETag", state.ETag)
+					}
+					if !state.LastModified.IsZero() {
+						header.Set(&response, "Last-Modified", state.LastModified.UTC().Format(http.TimeFormat))
+					}
+					return response, nil
+				case preconditionFailed:
+					return errorResponse, nil
+				}
+			}
+
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			if !safe {
+				return response, nil
+			}
+
+			if response.StatusCode == http.StatusOK && header.Response(&response).Get("ETag") == "" {
+				header.Set(&response, "ETag", generate(response.Body, config.weak))
+			}
+
+			// Preconditions have already been evaluated against the state
+			if config.stateFunc != nil || response.StatusCode < 200 || response.StatusCode >= 300 {
+				return response, nil
+			}
+
+			h := header.Response(&response)
+			state := State{ETag: h.Get("ETag"), Exists: true}
+			if t, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+				state.LastModified = t
+			}
+			switch evaluate(&request, safe, state) {
+			case notModified:
+				return toNotModified(response), nil
+			case preconditionFailed:
+				return errorResponse, nil
+			}
+			return response, nil
+		}
+	}
+}
+
+// generate computes an entity tag from the response body.
+func generate(body string, weak bool) string {
+	sum := sha256.Sum256([]byte(body))
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// hasPreconditions reports whether the request carries any conditional header.
+func hasPreconditions(request *events.APIGatewayProxyRequest) bool {
+	h := header.Request(request)
+	for _, key := range []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since"} {
+		if h.Get(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate evaluates the request preconditions against the resource state in the order defined by RFC 9110, Section 13.2.2.
+func evaluate(request *events.APIGatewayProxyRequest, safe bool, state State) outcome {
+	h := header.Request(request)
+	if ifMatch := strings.Join(h.Values("If-Match"), ", "); ifMatch != "" {
+		if !state.Exists || !matchAny(ifMatch, state.ETag, true) {
+			return preconditionFailed
+		}
+	} else if since, err := http.ParseTime(h.Get("If-Unmodified-Since")); err == nil && !state.LastModified.IsZero() {
+		if state.LastModified.Truncate(time.Second).After(since) {
+			return preconditionFailed
+		}
+	}
+
+	if ifNoneMatch := strings.Join(h.Values("If-None-Match"), ", "); ifNoneMatch != "" {
+		if state.Exists && matchAny(ifNoneMatch, state.ETag, false) {
+			if safe {
+				return notModified
+			}
+			return preconditionFailed
+		}
+	} else if since, err := http.ParseTime(h.Get("If-Modified-Since")); err == nil && safe && !state.LastModified.IsZero() {
+		if !state.LastModified.Truncate(time.Second).After(since) {
+			return notModified
+		}
+	}
+
+	return proceed
+}
+
+// matchAny reports whether the entity tag list matches current.
+// The strong comparison function is used if strong is true, the weak comparison function otherwise.
+// A list of "*" matches any current entity tag.
+func matchAny(list string, current string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if current == "" {
+		return false
+	}
+	currentWeak, currentOpaque := splitTag(current)
+	for _, tag := range parseTags(list) {
+		weak, opaque := splitTag(tag)
+		if opaque != currentOpaque {
+			continue
+		}
+		if !strong || (!weak && !currentWeak) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTag splits an entity tag into its weakness indicator and opaque tag (including the quotes).
+func splitTag(tag string) (bool, string) {
+	if strings.HasPrefix(tag, "W/") {
+		return true, tag[2:]
+	}
+	return false, tag
+}
+
+// parseTags parses a comma separated list of entity tags.
+// Commas inside quoted opaque tags are not treated as separators.
+func parseTags(list string) []string {
+	var tags []string
+	for len(list) > 0 {
+		list = strings.TrimLeft(list, " \t,")
+		if list == "" {
+			break
+		}
+		start := 0
+		if strings.HasPrefix(list, "W/") {
+			start = 2
+		}
+		if len(list) <= start || list[start] != '"' {
+			// Malformed element, skip to the next comma
+			_, list, _ = strings.Cut(list, ",")
+			continue
+		}
+		end := strings.IndexByte(list[start+1:], '"')
+		if end < 0 {
+			break
+		}
+		end += start + 2
+		tags = append(tags, list[:end])
+		list = list[end:]
+	}
+	return tags
+}
+
+// toNotModified converts a successful response into a 304 Not Modified response.
+// The body and the representation metadata describing it are removed, other headers are kept.
+func toNotModified(response events.APIGatewayProxyResponse) events.APIGatewayProxyResponse {
+	response.StatusCode = http.StatusNotModified
+	response.Body = ""
+	response.IsBase64Encoded = false
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Content-Language", "Content-Range"} {
+		header.Del(&response, key)
+	}
+	return response
+}
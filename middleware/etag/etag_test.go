@@ -0,0 +1,307 @@
+package etag
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// lastModified is the modification time reported by the test handlers.
+var lastModified = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newHandler returns a handler that responds with the given body and headers, and counts its calls.
+func newHandler(body string, headers map[string]string, calls *int) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		*calls++
+		h := map[string]string{"Content-Type": "application/json", "Cache-Control": "max-age=60"}
+		for k, v := range headers {
+			h[k] = v
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Headers: h, Body: body}, nil
+	}
+}
+
+// createRequest creates a request with the given method and headers.
+func createRequest(method string, headers map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{HTTPMethod: method, Path: "/items/1", Headers: headers}
+}
+
+func TestETag_Generate(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+
+	handler := ETag()(newHandler(`{"id":1}`, nil, &calls))
+	response, err := handler(context.Background(), createRequest("GET", nil))
+
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(generate(`{"id":1}`, false), response.Headers["ETag"])
+	assert.Regexp(`^"[A-Za-z0-9_-]+"$`, response.Headers["ETag"])
+
+	// Weak tags
+	handler = ETag(WithWeak(true))(newHandler(`{"id":1}`, nil, &calls))
+	response, err = handler(context.Background(), createRequest("GET", nil))
+	assert.NoError(err)
+	assert.Equal("W/"+generate(`{"id":1}`, false), response.Headers["ETag"])
+
+	// The handler's own tag is kept
+	handler = ETag()(newHandler(`{"id":1}`, map[string]string{"etag": `"v7"`}, &calls))
+	response, err = handler(context.Background(), createRequest("GET", nil))
+	assert.NoError(err)
+	assert.Equal(`"v7"`, response.Headers["etag"])
+	assert.NotContains(response.Headers, "ETag")
+
+	// No tag for unsafe methods
+	handler = ETag()(newHandler(`{"id":1}`, nil, &calls))
+	response, err = handler(context.Background(), createRequest("POST", nil))
+	assert.NoError(err)
+	assert.NotContains(response.Headers, "ETag")
+}
+
+func TestETag_ConditionalGet(t *testing.T) {
+	body := `{"id":1}`
+	tag := generate(body, false)
+
+	tests := []struct {
+		name               string
+		method             string
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		{
+			name:               "If-None-Match matches",
+			method:             "GET",
+			headers:            map[string]string{"If-None-Match": tag},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "If-None-Match matches weakly",
+			method:             "HEAD",
+			headers:            map[string]string{"If-None-Match": `"other", W/` + tag},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "If-None-Match wildcard",
+			method:             "GET",
+			headers:            map[string]string{"If-None-Match": "*"},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "If-None-Match does not match",
+			method:             "GET",
+			headers:            map[string]string{"If-None-Match": `"other"`},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "If-Modified-Since not modified",
+			method:             "GET",
+			headers:            map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "If-Modified-Since modified",
+			method:             "GET",
+			headers:            map[string]string{"If-Modified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat)},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "If-None-Match takes precedence over If-Modified-Since",
+			method: "GET",
+			headers: map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": lastModified.Format(http.TimeFormat),
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "If-Match fails",
+			method:             "GET",
+			headers:            map[string]string{"If-Match": `"other"`},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "If-Match uses strong comparison",
+			method:             "GET",
+			headers:            map[string]string{"If-Match": "W/" + tag},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "If-Match succeeds",
+			method:             "GET",
+			headers:            map[string]string{"if-match": tag},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "If-Unmodified-Since fails",
+			method:             "GET",
+			headers:            map[string]string{"If-Unmodified-Since": lastModified.Add(-time.Hour).Format(http.TimeFormat)},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			calls := 0
+
+			handler := ETag()(newHandler(body, map[string]string{"Last-Modified": lastModified.Format(http.TimeFormat)}, &calls))
+			response, err := handler(context.Background(), createRequest(tt.method, tt.headers))
+
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatusCode, response.StatusCode)
+			switch tt.expectedStatusCode {
+			case http.StatusNotModified:
+				assert.Empty(response.Body)
+				assert.Equal(tag, response.Headers["ETag"])
+				assert.Equal("max-age=60", response.Headers["Cache-Control"])
+				assert.NotContains(response.Headers, "Content-Type")
+			case http.StatusPreconditionFailed:
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			default:
+				assert.Equal(body, response.Body)
+			}
+		})
+	}
+}
+
+func TestETag_StateFunc(t *testing.T) {
+	state := State{ETag: `"v2"`, LastModified: lastModified, Exists: true}
+	stateFunc := func(ctx context.Context, request events.APIGatewayProxyRequest) (State, error) {
+		return state, nil
+	}
+
+	tests := []struct {
+		name               string
+		method             string
+		headers            map[string]string
+		expectedStatusCode int
+		expectNextCalled   bool
+	}{
+		{
+			name:               "Write with matching If-Match",
+			method:             "PUT",
+			headers:            map[string]string{"If-Match": `"v1", "v2"`},
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "Write with stale If-Match",
+			method:             "PUT",
+			headers:            map[string]string{"If-Match": `"v1"`},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectNextCalled:   false,
+		},
+		{
+			name:               "Write with If-Unmodified-Since",
+			method:             "DELETE",
+			headers:            map[string]string{"If-Unmodified-Since": lastModified.Format(http.TimeFormat)},
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+		{
+			name:               "Write with stale If-Unmodified-Since",
+			method:             "PATCH",
+			headers:            map[string]string{"If-Unmodified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectNextCalled:   false,
+		},
+		{
+			name:               "Create only if absent",
+			method:             "PUT",
+			headers:            map[string]string{"If-None-Match": "*"},
+			expectedStatusCode: http.StatusPreconditionFailed,
+			expectNextCalled:   false,
+		},
+		{
+			name:               "Conditional GET answered without handler",
+			method:             "GET",
+			headers:            map[string]string{"If-None-Match": `"v2"`},
+			expectedStatusCode: http.StatusNotModified,
+			expectNextCalled:   false,
+		},
+		{
+			name:               "No preconditions",
+			method:             "PUT",
+			headers:            nil,
+			expectedStatusCode: http.StatusOK,
+			expectNextCalled:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			calls := 0
+
+			handler := ETag(WithStateFunc(stateFunc))(newHandler("{}", nil, &calls))
+			response, err := handler(context.Background(), createRequest(tt.method, tt.headers))
+
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatusCode, response.StatusCode)
+			assert.Equal(tt.expectNextCalled, calls == 1)
+			if tt.expectedStatusCode == http.StatusNotModified {
+				assert.Equal(`"v2"`, response.Headers["ETag"])
+				assert.Equal(lastModified.Format(http.TimeFormat), response.Headers["Last-Modified"])
+			}
+		})
+	}
+}
+
+func TestETag_StateFuncMissingResource(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	stateFunc := func(ctx context.Context, request events.APIGatewayProxyRequest) (State, error) {
+		return State{}, nil
+	}
+	handler := ETag(WithStateFunc(stateFunc))(newHandler("{}", nil, &calls))
+
+	// Creating a missing resource is allowed with If-None-Match: *
+	response, err := handler(context.Background(), createRequest("PUT", map[string]string{"If-None-Match": "*"}))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// If-Match: * requires the resource to exist
+	response, err = handler(context.Background(), createRequest("PUT", map[string]string{"If-Match": "*"}))
+	assert.NoError(err)
+	assert.Equal(http.StatusPreconditionFailed, response.StatusCode)
+}
+
+func TestETag_StateFuncError(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+	stateErr := errors.New("lookup failed")
+	stateFunc := func(ctx context.Context, request events.APIGatewayProxyRequest) (State, error) {
+		return State{}, stateErr
+	}
+
+	handler := ETag(WithStateFunc(stateFunc))(newHandler("{}", nil, &calls))
+	_, err := handler(context.Background(), createRequest("PUT", map[string]string{"If-Match": `"v1"`}))
+
+	assert.Equal(stateErr, err)
+	assert.Equal(0, calls)
+}
+
+func TestETag_WithResponse(t *testing.T) {
+	assert := assert.New(t)
+	calls := 0
+
+	handler := ETag(WithResponse("application/json", `{"error":"stale"}`))(newHandler("{}", nil, &calls))
+	response, err := handler(context.Background(), createRequest("GET", map[string]string{"If-Match": `"stale"`}))
+
+	assert.NoError(err)
+	assert.Equal(http.StatusPreconditionFailed, response.StatusCode)
+	assert.Equal(`{"error":"stale"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{`"a"`, `W/"b"`, `"c,d"`}, parseTags(` "a", W/"b" ,"c,d"`))
+	assert.Equal(t, []string{`"b"`}, parseTags(`bogus, "b"`))
+	assert.Empty(t, parseTags(`"unterminated`))
+}
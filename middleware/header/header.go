@@ -0,0 +1,113 @@
+package header
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Request returns the headers of the request as an http.Header, so that they can be read
+// regardless of the case of their names (e.g. "content-type" sent by HTTP/2 clients).
+//
+// Values are taken from MultiValueHeaders, and from Headers for names missing there.
+// The returned header is a copy: modifying it does not affect the request.
+func Request(request *events.APIGatewayProxyRequest) http.Header {
+	return merge(request.Headers, request.MultiValueHeaders)
+}
+
+// Response returns the headers of the response as an http.Header, built like Request.
+// The returned header is a copy: use Set, Add and Del to modify the response.
+func Response(response *events.APIGatewayProxyResponse) http.Header {
+	return merge(response.Headers, response.MultiValueHeaders)
+}
+
+// Set sets the named response header to value, replacing any existing values regardless of the case of their names
+// in both Headers and MultiValueHeaders. The value is stored in Headers under key as given.
+func Set(response *events.APIGatewayProxyResponse, key, value string) {
+	Del(response, key)
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	response.Headers[key] = value
+}
+
+// SetDefault sets the named response header to value unless it is already present, whatever the case of its name.
+func SetDefault(response *events.APIGatewayProxyResponse, key, value string) {
+	if _, ok := Response(response)[http.CanonicalHeaderKey(key)]; ok {
+		return
+	}
+	Set(response, key, value)
+}
+
+// Add appends value to the named response header, keeping the existing values.
+//
+// As Headers can only hold one value per name, the values are stored in MultiValueHeaders:
+// a value found in Headers is moved there first. An existing MultiValueHeaders key is reused, whatever its case;
+// otherwise the values are stored under key as given.
+func Add(response *events.APIGatewayProxyResponse, key, value string) {
+	mvKey := key
+	for k := range response.MultiValueHeaders {
+		if strings.EqualFold(k, key) {
+			mvKey = k
+			break
+		}
+	}
+	if response.MultiValueHeaders == nil {
+		response.MultiValueHeaders = make(map[string][]string)
+	}
+	for k, v := range response.Headers {
+		if strings.EqualFold(k, key) {
+			response.MultiValueHeaders[mvKey] = append(response.MultiValueHeaders[mvKey], v)
+			delete(response.Headers, k)
+		}
+	}
+	response.MultiValueHeaders[mvKey] = append(response.MultiValueHeaders[mvKey], value)
+}
+
+// AddVary adds field to the Vary response header unless it is already listed or Vary is "*".
+// The values of all Vary headers are merged into a single comma-separated value.
+func AddVary(response *events.APIGatewayProxyResponse, field string) {
+	vary := strings.Join(Response(response).Values("Vary"), ", ")
+	for v := range strings.SplitSeq(vary, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.EqualFold(v, field) {
+			return
+		}
+	}
+	if strings.TrimSpace(vary) != "" {
+		field = vary + ", " + field
+	}
+	Set(response, "Vary", field)
+}
+
+// Del removes the named response header from both Headers and MultiValueHeaders, ignoring the case of the name.
+func Del(response *events.APIGatewayProxyResponse, key string) {
+	for k := range response.Headers {
+		if strings.EqualFold(k, key) {
+			delete(response.Headers, k)
+		}
+	}
+	for k := range response.MultiValueHeaders {
+		if strings.EqualFold(k, key) {
+			delete(response.MultiValueHeaders, k)
+		}
+	}
+}
+
+// merge builds an http.Header from single and multi-value header maps.
+func merge(headers map[string]string, multiValueHeaders map[string][]string) http.Header {
+	h := make(http.Header, len(headers)+len(multiValueHeaders))
+	for k, v := range multiValueHeaders {
+		for _, value := range v {
+			h.Add(k, value)
+		}
+	}
+	for k, v := range headers {
+		key := http.CanonicalHeaderKey(k)
+		if _, ok := h[key]; !ok {
+			h[key] = []string{v}
+		}
+	}
+	return h
+}
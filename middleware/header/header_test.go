@@ -0,0 +1,168 @@
+package header
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		request events.APIGatewayProxyRequest
+		want    http.Header
+	}{
+		{
+			name:    "no headers",
+			request: events.APIGatewayProxyRequest{},
+			want:    http.Header{},
+		},
+		{
+			name:    "lower case names",
+			request: events.APIGatewayProxyRequest{Headers: map[string]string{"content-type": "application/json", "x-api-key": "k"}},
+			want:    http.Header{"Content-Type": {"application/json"}, "X-Api-Key": {"k"}},
+		},
+		{
+			name: "multi value headers take precedence",
+			request: events.APIGatewayProxyRequest{
+				Headers:           map[string]string{"Accept": "text/html", "Host": "example.com"},
+				MultiValueHeaders: map[string][]string{"accept": {"application/json", "text/html"}},
+			},
+			want: http.Header{"Accept": {"application/json", "text/html"}, "Host": {"example.com"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Request(&tt.request)
+			assert.Equal(t, tt.want, h)
+		})
+	}
+}
+
+func TestRequest_Copy(t *testing.T) {
+	request := events.APIGatewayProxyRequest{Headers: map[string]string{"X-Test": "a"}}
+	h := Request(&request)
+	h.Set("X-Test", "b")
+	assert.Equal(t, "a", request.Headers["X-Test"])
+}
+
+func TestResponse(t *testing.T) {
+	response := events.APIGatewayProxyResponse{
+		Headers:           map[string]string{"content-type": "text/plain"},
+		MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+	}
+	h := Response(&response)
+	assert.Equal(t, "text/plain", h.Get("Content-Type"))
+	assert.Equal(t, []string{"a=1", "b=2"}, h.Values("set-cookie"))
+}
+
+func TestSet(t *testing.T) {
+	response := events.APIGatewayProxyResponse{
+		Headers:           map[string]string{"content-type": "text/plain", "X-Other": "x"},
+		MultiValueHeaders: map[string][]string{"CONTENT-TYPE": {"text/html"}},
+	}
+	Set(&response, "Content-Type", "application/json")
+	assert.Equal(t, map[string]string{"Content-Type": "application/json", "X-Other": "x"}, response.Headers)
+	assert.Empty(t, response.MultiValueHeaders)
+
+	// Nil maps
+	response = events.APIGatewayProxyResponse{}
+	Set(&response, "Vary", "Accept")
+	assert.Equal(t, map[string]string{"Vary": "Accept"}, response.Headers)
+}
+
+func TestSetDefault(t *testing.T) {
+	response := events.APIGatewayProxyResponse{
+		Headers:           map[string]string{"cache-control": "no-store"},
+		MultiValueHeaders: map[string][]string{"x-frame-options": {"SAMEORIGIN"}},
+	}
+	SetDefault(&response, "Cache-Control", "public")
+	SetDefault(&response, "X-Frame-Options", "DENY")
+	SetDefault(&response, "Expires", "0")
+	assert.Equal(t, map[string]string{"cache-control": "no-store", "Expires": "0"}, response.Headers)
+	assert.Equal(t, map[string][]string{"x-frame-options": {"SAMEORIGIN"}}, response.MultiValueHeaders)
+}
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		response events.APIGatewayProxyResponse
+		want     map[string][]string
+	}{
+		{
+			name:     "no header",
+			response: events.APIGatewayProxyResponse{},
+			want:     map[string][]string{"Set-Cookie": {"b=2"}},
+		},
+		{
+			name:     "single value header is moved",
+			response: events.APIGatewayProxyResponse{Headers: map[string]string{"set-cookie": "a=1"}},
+			want:     map[string][]string{"Set-Cookie": {"a=1", "b=2"}},
+		},
+		{
+			name:     "existing multi value key is reused",
+			response: events.APIGatewayProxyResponse{MultiValueHeaders: map[string][]string{"set-cookie": {"a=1"}}},
+			want:     map[string][]string{"set-cookie": {"a=1", "b=2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Add(&tt.response, "Set-Cookie", "b=2")
+			assert.Equal(t, tt.want, tt.response.MultiValueHeaders)
+			assert.Empty(t, tt.response.Headers)
+		})
+	}
+}
+
+func TestDel(t *testing.T) {
+	response := events.APIGatewayProxyResponse{
+		Headers:           map[string]string{"etag": `"a"`, "X-Other": "x"},
+		MultiValueHeaders: map[string][]string{"ETag": {`"b"`}},
+	}
+	Del(&response, "ETag")
+	assert.Equal(t, map[string]string{"X-Other": "x"}, response.Headers)
+	assert.Empty(t, response.MultiValueHeaders)
+}
+
+func TestAddVary(t *testing.T) {
+	tests := []struct {
+		name     string
+		response events.APIGatewayProxyResponse
+		want     string
+	}{
+		{
+			name:     "no header",
+			response: events.APIGatewayProxyResponse{},
+			want:     "Accept-Encoding",
+		},
+		{
+			name:     "appended",
+			response: events.APIGatewayProxyResponse{Headers: map[string]string{"vary": "Origin"}},
+			want:     "Origin, Accept-Encoding",
+		},
+		{
+			name:     "multi value headers are merged",
+			response: events.APIGatewayProxyResponse{MultiValueHeaders: map[string][]string{"Vary": {"Origin", "Accept"}}},
+			want:     "Origin, Accept, Accept-Encoding",
+		},
+		{
+			name:     "already listed",
+			response: events.APIGatewayProxyResponse{Headers: map[string]string{"Vary": "Origin, accept-encoding"}},
+			want:     "Origin, accept-encoding",
+		},
+		{
+			name:     "wildcard",
+			response: events.APIGatewayProxyResponse{Headers: map[string]string{"Vary": "*"}},
+			want:     "*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			AddVary(&tt.response, "Accept-Encoding")
+			assert.Equal(t, tt.want, Response(&tt.response).Get("Vary"))
+			assert.Len(t, Response(&tt.response).Values("Vary"), 1)
+		})
+	}
+}
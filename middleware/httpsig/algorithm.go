@@ -0,0 +1,162 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Signature algorithms registered by RFC 9421.
+const (
+	RSAPSSSHA512    = "rsa-pss-sha512"
+	RSAV15SHA256    = "rsa-v1_5-sha256"
+	HMACSHA256      = "hmac-sha256"
+	ECDSAP256SHA256 = "ecdsa-p256-sha256"
+	ECDSAP384SHA384 = "ecdsa-p384-sha384"
+	Ed25519         = "ed25519"
+)
+
+// errInvalidSignature is returned when a signature does not match the signature base.
+var errInvalidSignature = errors.New("httpsig: invalid signature")
+
+// errKeyType is returned when the key type does not match the algorithm.
+var errKeyType = errors.New("httpsig: key type does not match the algorithm")
+
+// sign signs base with key using algorithm.
+// key is a []byte for HMAC, or an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+func sign(algorithm string, key any, base []byte) ([]byte, error) {
+	switch algorithm {
+	case HMACSHA256:
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, errKeyType
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(base)
+		return mac.Sum(nil), nil
+	case RSAPSSSHA512:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errKeyType
+		}
+		digest := sha512.Sum512(base)
+		return rsa.SignPSS(rand.Reader, k, crypto.SHA512, digest[:], &rsa.PSSOptions{SaltLength: 64})
+	case RSAV15SHA256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errKeyType
+		}
+		digest := sha256.Sum256(base)
+		return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case ECDSAP256SHA256, ECDSAP384SHA384:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok || k.Curve != curve(algorithm) {
+			return nil, errKeyType
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, k, ecdsaDigest(algorithm, base))
+		if err != nil {
+			return nil, err
+		}
+		// The signature is the concatenation of r and s, each padded to the curve size
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return signature, nil
+	case Ed25519:
+		k, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errKeyType
+		}
+		return ed25519.Sign(k, base), nil
+	}
+	return nil, fmt.Errorf("httpsig: unsupported algorithm %q", algorithm)
+}
+
+// verify checks that signature is a valid signature of base with key using algorithm.
+// key is a []byte for HMAC, or an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func verify(algorithm string, key any, base, signature []byte) error {
+	switch algorithm {
+	case HMACSHA256:
+		expected, err := sign(algorithm, key, base)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(expected, signature) {
+			return errInvalidSignature
+		}
+		return nil
+	case RSAPSSSHA512:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errKeyType
+		}
+		digest := sha512.Sum512(base)
+		if rsa.VerifyPSS(k, crypto.SHA512, digest[:], signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) != nil {
+			return errInvalidSignature
+		}
+		return nil
+	case RSAV15SHA256:
+		k, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errKeyType
+		}
+		digest := sha256.Sum256(base)
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return errInvalidSignature
+		}
+		return nil
+	case ECDSAP256SHA256, ECDSAP384SHA384:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve != curve(algorithm) {
+			return errKeyType
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, ecdsaDigest(algorithm, base), r, s) {
+			return errInvalidSignature
+		}
+		return nil
+	case Ed25519:
+		k, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errKeyType
+		}
+		if !ed25519.Verify(k, base, signature) {
+			return errInvalidSignature
+		}
+		return nil
+	}
+	return fmt.Errorf("httpsig: unsupported algorithm %q", algorithm)
+}
+
+// curve returns the elliptic curve of an ECDSA algorithm.
+func curve(algorithm string) elliptic.Curve {
+	if algorithm == ECDSAP384SHA384 {
+		return elliptic.P384()
+	}
+	return elliptic.P256()
+}
+
+// ecdsaDigest returns the digest of base for an ECDSA algorithm.
+func ecdsaDigest(algorithm string, base []byte) []byte {
+	if algorithm == ECDSAP384SHA384 {
+		digest := sha512.Sum384(base)
+		return digest[:]
+	}
+	digest := sha256.Sum256(base)
+	return digest[:]
+}
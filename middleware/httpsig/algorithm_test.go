@@ -0,0 +1,67 @@
+package httpsig
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test keys shared by the tests of this package.
+var (
+	rsaKey, _            = rsa.GenerateKey(rand.Reader, 2048)
+	p256Key, _           = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384Key, _           = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	ed25519Public, edKey = func() (ed25519.PublicKey, ed25519.PrivateKey) {
+		pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+		return pub, priv
+	}()
+	hmacSecret = []byte("shared secret")
+)
+
+func TestSignVerify(t *testing.T) {
+	tests := []struct {
+		algorithm  string
+		privateKey any
+		publicKey  any
+		wrongKey   any
+	}{
+		{HMACSHA256, hmacSecret, hmacSecret, &rsaKey.PublicKey},
+		{RSAPSSSHA512, rsaKey, &rsaKey.PublicKey, hmacSecret},
+		{RSAV15SHA256, rsaKey, &rsaKey.PublicKey, &p256Key.PublicKey},
+		{ECDSAP256SHA256, p256Key, &p256Key.PublicKey, &p384Key.PublicKey},
+		{ECDSAP384SHA384, p384Key, &p384Key.PublicKey, &p256Key.PublicKey},
+		{Ed25519, edKey, ed25519Public, &p256Key.PublicKey},
+	}
+
+	base := []byte(`"@method": POST` + "\n" + `"@signature-params": ("@method")`)
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			assert := assert.New(t)
+			signature, err := sign(tt.algorithm, tt.privateKey, base)
+			assert.NoError(err)
+			assert.NoError(verify(tt.algorithm, tt.publicKey, base, signature))
+
+			// Tampered base
+			assert.ErrorIs(verify(tt.algorithm, tt.publicKey, append([]byte("x"), base...), signature), errInvalidSignature)
+			// Key of another type or curve
+			assert.ErrorIs(verify(tt.algorithm, tt.wrongKey, base, signature), errKeyType)
+			_, err = sign(tt.algorithm, tt.wrongKey, base)
+			assert.ErrorIs(err, errKeyType)
+		})
+	}
+
+	// ECDSA signatures are r || s padded to the curve size
+	signature, err := sign(ECDSAP256SHA256, p256Key, base)
+	assert.NoError(t, err)
+	assert.Len(t, signature, 64)
+	assert.ErrorIs(t, verify(ECDSAP256SHA256, &p256Key.PublicKey, base, signature[:63]), errInvalidSignature)
+
+	_, err = sign("unknown", hmacSecret, base)
+	assert.Error(t, err)
+	assert.Error(t, verify("unknown", hmacSecret, base, nil))
+}
@@ -0,0 +1,66 @@
+package httpsig
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+)
+
+// Digest algorithms registered by RFC 9530.
+const (
+	DigestSHA256 = "sha-256"
+	DigestSHA512 = "sha-512"
+)
+
+var (
+	// errDigestMissing is returned when a Content-Digest with a supported algorithm is required but absent.
+	errDigestMissing = errors.New("httpsig: missing Content-Digest")
+
+	// errDigestMismatch is returned when the Content-Digest does not match the body.
+	errDigestMismatch = errors.New("httpsig: Content-Digest mismatch")
+)
+
+// digestHashes maps the supported digest algorithms to their hash functions.
+var digestHashes = map[string]func() hash.Hash{
+	DigestSHA256: sha256.New,
+	DigestSHA512: sha512.New,
+}
+
+// contentDigest returns the Content-Digest field value of body using algorithm.
+func contentDigest(algorithm string, body []byte) string {
+	h := digestHashes[algorithm]()
+	h.Write(body)
+	return serializeDictionary([]member{{key: algorithm, item: item{value: h.Sum(nil)}}})
+}
+
+// verifyContentDigest checks a Content-Digest field value against body.
+// Unknown algorithms are ignored, and every supported algorithm present must match.
+func verifyContentDigest(value string, body []byte) error {
+	members, err := parseDictionary(value)
+	if err != nil {
+		return err
+	}
+	verified := false
+	for _, m := range members {
+		newHash, ok := digestHashes[m.key]
+		if !ok {
+			continue
+		}
+		expected, ok := m.item.value.([]byte)
+		if !ok {
+			return errDigestMismatch
+		}
+		h := newHash()
+		h.Write(body)
+		if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+			return errDigestMismatch
+		}
+		verified = true
+	}
+	if !verified {
+		return errDigestMissing
+	}
+	return nil
+}
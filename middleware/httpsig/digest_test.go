@@ -0,0 +1,114 @@
+package httpsig
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// Example from RFC 9530
+const (
+	digestBody   = `{"hello": "world"}`
+	digestSHA256 = "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+	digestSHA512 = "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"
+)
+
+func TestContentDigestValue(t *testing.T) {
+	assert.Equal(t, digestSHA256, contentDigest(DigestSHA256, []byte(digestBody)))
+	assert.Equal(t, digestSHA512, contentDigest(DigestSHA512, []byte(digestBody)))
+}
+
+func TestContentDigest(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  events.APIGatewayProxyRequest
+		opts     []Option
+		expected bool
+	}{
+		{
+			name:     "SHA-256",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Digest": digestSHA256}, Body: digestBody},
+			expected: true,
+		},
+		{
+			name:     "SHA-512 and unknown algorithm",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"content-digest": "md5=:AAAA:, " + digestSHA512}, Body: digestBody},
+			expected: true,
+		},
+		{
+			name: "Multi-value header and base64 body",
+			request: events.APIGatewayProxyRequest{
+				MultiValueHeaders: map[string][]string{"Content-Digest": {digestSHA256, digestSHA512}},
+				Body:              base64.StdEncoding.EncodeToString([]byte(digestBody)),
+				IsBase64Encoded:   true,
+			},
+			expected: true,
+		},
+		{
+			name:     "Mismatch",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Digest": digestSHA256}, Body: `{"hello": "there"}`},
+			expected: false,
+		},
+		{
+			name:     "One of several digests mismatches",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Digest": digestSHA256 + ", sha-512=:AAAA:"}, Body: digestBody},
+			expected: false,
+		},
+		{
+			name:     "Only unknown algorithms",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Digest": "md5=:AAAA:"}, Body: digestBody},
+			expected: false,
+		},
+		{
+			name:     "Malformed header",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Digest": "sha-256=abc"}, Body: digestBody},
+			expected: false,
+		},
+		{
+			name:     "Missing header",
+			request:  events.APIGatewayProxyRequest{Body: digestBody},
+			expected: false,
+		},
+		{
+			name:     "Missing header without body",
+			request:  events.APIGatewayProxyRequest{},
+			expected: true,
+		},
+		{
+			name:     "Missing header, not required",
+			request:  events.APIGatewayProxyRequest{Body: digestBody},
+			opts:     []Option{WithDigestRequired(false)},
+			expected: true,
+		},
+		{
+			name:     "Mismatch, not required",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Digest": digestSHA256}, Body: "tampered"},
+			opts:     []Option{WithDigestRequired(false)},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			nextCalled := false
+			handler := ContentDigest(tt.opts...)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				nextCalled = true
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), tt.request)
+			assert.NoError(err)
+			assert.Equal(tt.expected, nextCalled)
+			if !tt.expected {
+				assert.Equal(http.StatusBadRequest, response.StatusCode)
+				assert.Equal(defaultDigestErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
@@ -0,0 +1,522 @@
+package httpsig
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultErrorBody is the default response body when a signature cannot be verified.
+	defaultErrorBody = "Unauthorized"
+
+	// defaultDigestErrorBody is the default response body when the Content-Digest cannot be verified.
+	defaultDigestErrorBody = "Bad Request"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+
+	// defaultLabel is the default label of response signatures.
+	defaultLabel = "sig1"
+)
+
+var (
+	// errNoSignature is returned when the request carries no signature to verify.
+	errNoSignature = errors.New("httpsig: no signature")
+
+	// errNotCovered is returned when a signature does not cover the required components.
+	errNotCovered = errors.New("httpsig: required component not covered")
+
+	// errExpired is returned for signatures that are expired or too old.
+	errExpired = errors.New("httpsig: signature expired")
+
+	// errAlgorithm is returned when the "alg" parameter does not match the key.
+	errAlgorithm = errors.New("httpsig: algorithm does not match the key")
+
+	// errKeyNotFound is returned by StaticKeys for unknown key IDs.
+	errKeyNotFound = errors.New("httpsig: key not found")
+)
+
+// defaultVerifyComponents are the components a request signature must cover by default.
+var defaultVerifyComponents = []string{"@method", "@authority", "@path"}
+
+// defaultSignComponents are the components covered by response signatures by default.
+var defaultSignComponents = []string{"@status", "content-type", "content-digest"}
+
+// CtxKey is the default key type used to store the verified Result within the context.
+type CtxKey struct{}
+
+// Key is a signing or verification key together with its algorithm.
+//
+// For verification, Key is a []byte for HMAC, or an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+// For signing, Key is a []byte for HMAC, or an *rsa.PrivateKey, *ecdsa.PrivateKey or ed25519.PrivateKey.
+type Key struct {
+	// Algorithm is one of the algorithms registered by RFC 9421, such as Ed25519 or ECDSAP256SHA256.
+	Algorithm string
+	// Key is the key material.
+	Key any
+}
+
+// KeyResolver is the interface to look up the verification key for a "keyid" parameter.
+type KeyResolver interface {
+	ResolveKey(ctx context.Context, keyID string) (Key, error)
+}
+
+// KeyResolverFunc is an adapter to allow the use of ordinary functions as KeyResolver.
+type KeyResolverFunc func(ctx context.Context, keyID string) (Key, error)
+
+// ResolveKey calls f(ctx, keyID).
+func (f KeyResolverFunc) ResolveKey(ctx context.Context, keyID string) (Key, error) {
+	return f(ctx, keyID)
+}
+
+// StaticKeys returns a KeyResolver serving the given keys, indexed by key ID.
+func StaticKeys(keys map[string]Key) KeyResolver {
+	return KeyResolverFunc(func(ctx context.Context, keyID string) (Key, error) {
+		key, ok := keys[keyID]
+		if !ok {
+			return Key{}, errKeyNotFound
+		}
+		return key, nil
+	})
+}
+
+// Result describes a verified request signature.
+type Result struct {
+	// Label is the label of the signature in the Signature and Signature-Input headers.
+	Label string
+	// KeyID is the "keyid" parameter of the signature.
+	KeyID string
+	// Components are the covered components.
+	Components []string
+	// Created is the "created" parameter of the signature, or the zero time if absent.
+	Created time.Time
+}
+
+// Config is the configuration for the Verify, ContentDigest and SignResponse middleware.
+type Config struct {
+	ctxKey           any
+	label            string
+	components       []string
+	maxAge           time.Duration
+	digestRequired   bool
+	now              func() time.Time
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the Verify, ContentDigest and SignResponse configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the verified Result to be set in the context by Verify.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithLabel sets the signature label. Verify only checks the signature with this label,
+// and SignResponse uses it for the response signature (default "sig1").
+func WithLabel(label string) Option {
+	return func(c *Config) {
+		c.label = label
+	}
+}
+
+// WithComponents sets the components that request signatures must cover (Verify),
+// or that response signatures cover (SignResponse).
+//
+// The defaults are "@method", "@authority" and "@path" for Verify,
+// and "@status", "content-type" and "content-digest" for SignResponse.
+func WithComponents(components ...string) Option {
+	return func(c *Config) {
+		c.components = components
+	}
+}
+
+// WithMaxAge makes Verify reject signatures whose "created" parameter is missing or older than maxAge.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *Config) {
+		c.maxAge = maxAge
+	}
+}
+
+// WithDigestRequired specifies whether ContentDigest rejects requests with a body but no Content-Digest.
+// The default is true.
+func WithDigestRequired(required bool) Option {
+	return func(c *Config) {
+		c.digestRequired = required
+	}
+}
+
+// WithClock sets the function returning the current time.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when verification fails.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// newConfig builds the configuration from the default values and opts.
+func newConfig(defaultComponents []string, defaultBody string, opts []Option) Config {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		components:       defaultComponents,
+		digestRequired:   true,
+		now:              time.Now,
+		errorBody:        defaultBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// Verify creates middleware that verifies HTTP Message Signatures (RFC 9421) of requests.
+//
+// Each signature of the Signature-Input and Signature headers is checked with the key returned by keys
+// for its "keyid" parameter, until one is valid and covers the required components (see WithComponents).
+// The supported derived components are "@method", "@authority", "@path" and "@scheme"; other
+// components are request header fields. Signatures with an "expires" parameter in the past are rejected.
+//
+// On success the Result is set in the context under CtxKey{} (or the key given with WithCtxKey).
+// Otherwise, the middleware returns 401 Unauthorized. Combine with ContentDigest and cover
+// "content-digest" to protect the body.
+//
+// Example:
+//
+//	keys := httpsig.StaticKeys(map[string]httpsig.Key{
+//	    "partner-1": {Algorithm: httpsig.Ed25519, Key: partnerPublicKey},
+//	})
+//	handler := middleware.Use(myHandler,
+//	    httpsig.Verify(keys, httpsig.WithComponents("@method", "@authority", "@path", "content-digest")),
+//	    httpsig.ContentDigest(),
+//	)
+func Verify(keys KeyResolver, opts ...Option) middleware.MiddlewareFunc {
+	config := newConfig(defaultVerifyComponents, defaultErrorBody, opts)
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			result, err := verifyRequest(ctx, &config, keys, &request)
+			if err != nil {
+				return errorResponse, nil
+			}
+			ctxWithResult := context.WithValue(ctx, config.ctxKey, result)
+			return next(ctxWithResult, request)
+		}
+	}
+}
+
+// ContentDigest creates middleware that verifies the Content-Digest header (RFC 9530) against the request body
+// (base64-decoded if needed). The sha-256 and sha-512 algorithms are supported; others are ignored.
+//
+// Requests with a mismatching digest, or with a body but no supported digest (unless WithDigestRequired(false)),
+// are rejected with 400 Bad Request.
+func ContentDigest(opts ...Option) middleware.MiddlewareFunc {
+	config := newConfig(nil, defaultDigestErrorBody, opts)
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusBadRequest,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			body, err := decodeBody(request.Body, request.IsBase64Encoded)
+			if err != nil {
+				return errorResponse, nil
+			}
+			value := strings.Join(header.Request(&request).Values("Content-Digest"), ", ")
+			if value == "" {
+				if len(body) > 0 && config.digestRequired {
+					return errorResponse, nil
+				}
+				return next(ctx, request)
+			}
+			if err := verifyContentDigest(value, body); err != nil {
+				if !errors.Is(err, errDigestMissing) || config.digestRequired {
+					return errorResponse, nil
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// SignResponse creates middleware that signs responses with HTTP Message Signatures (RFC 9421).
+//
+// A Content-Digest header (sha-256) is added to the response if it has none. Then the covered components
+// (see WithComponents) are signed with key, and the Signature-Input and Signature headers are set,
+// with the "created", "keyid" and "alg" parameters. Header fields absent from the response are not covered.
+// The supported derived component is "@status".
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, httpsig.SignResponse("our-key", httpsig.Key{Algorithm: httpsig.Ed25519, Key: privateKey}))
+func SignResponse(keyID string, key Key, opts ...Option) middleware.MiddlewareFunc {
+	config := newConfig(defaultSignComponents, defaultErrorBody, opts)
+	if config.label == "" {
+		config.label = defaultLabel
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			if header.Response(&response).Get("Content-Digest") == "" {
+				body, err := decodeBody(response.Body, response.IsBase64Encoded)
+				if err != nil {
+					return response, fmt.Errorf("httpsig: %w", err)
+				}
+				header.Set(&response, "Content-Digest", contentDigest(DigestSHA256, body))
+			}
+
+			var components []item
+			for _, name := range config.components {
+				if !strings.HasPrefix(name, "@") && header.Response(&response).Get(name) == "" {
+					continue
+				}
+				components = append(components, item{value: strings.ToLower(name)})
+			}
+			params := item{
+				value: components,
+				params: []param{
+					{key: "created", value: config.now().Unix()},
+					{key: "keyid", value: keyID},
+					{key: "alg", value: key.Algorithm},
+				},
+			}
+
+			base, err := signatureBase(params, func(name string) (string, error) {
+				return responseComponent(&response, name)
+			})
+			if err != nil {
+				return response, err
+			}
+			signature, err := sign(key.Algorithm, key.Key, base)
+			if err != nil {
+				return response, err
+			}
+
+			header.Set(&response, "Signature-Input", serializeDictionary([]member{{key: config.label, item: params}}))
+			header.Set(&response, "Signature", serializeDictionary([]member{{key: config.label, item: item{value: signature}}}))
+			return response, nil
+		}
+	}
+}
+
+// verifyRequest verifies the signatures of request until one is valid.
+func verifyRequest(ctx context.Context, config *Config, keys KeyResolver, request *events.APIGatewayProxyRequest) (Result, error) {
+	inputs, err := parseDictionary(strings.Join(header.Request(request).Values("Signature-Input"), ", "))
+	if err != nil {
+		return Result{}, err
+	}
+	signatures, err := parseDictionary(strings.Join(header.Request(request).Values("Signature"), ", "))
+	if err != nil {
+		return Result{}, err
+	}
+
+	lastErr := errNoSignature
+	for _, input := range inputs {
+		if config.label != "" && input.key != config.label {
+			continue
+		}
+		i := slices.IndexFunc(signatures, func(m member) bool { return m.key == input.key })
+		if i < 0 {
+			continue
+		}
+		signature, ok := signatures[i].item.value.([]byte)
+		if !ok {
+			lastErr = errSyntax
+			continue
+		}
+		result, err := verifySignature(ctx, config, keys, request, input, signature)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+	}
+	return Result{}, lastErr
+}
+
+// verifySignature verifies a single signature of request.
+func verifySignature(ctx context.Context, config *Config, keys KeyResolver, request *events.APIGatewayProxyRequest, input member, signature []byte) (Result, error) {
+	list, ok := input.item.value.([]item)
+	if !ok {
+		return Result{}, errSyntax
+	}
+	result := Result{Label: input.key}
+	for _, component := range list {
+		name, ok := component.value.(string)
+		if !ok {
+			return Result{}, errSyntax
+		}
+		result.Components = append(result.Components, name)
+	}
+	for _, required := range config.components {
+		if !slices.Contains(result.Components, strings.ToLower(required)) {
+			return Result{}, errNotCovered
+		}
+	}
+
+	now := config.now()
+	if created, ok := input.item.param("created"); ok {
+		seconds, ok := created.(int64)
+		if !ok {
+			return Result{}, errSyntax
+		}
+		result.Created = time.Unix(seconds, 0)
+	}
+	if config.maxAge > 0 && (result.Created.IsZero() || now.Sub(result.Created) > config.maxAge) {
+		return Result{}, errExpired
+	}
+	if expires, ok := input.item.param("expires"); ok {
+		seconds, ok := expires.(int64)
+		if !ok {
+			return Result{}, errSyntax
+		}
+		if !now.Before(time.Unix(seconds, 0)) {
+			return Result{}, errExpired
+		}
+	}
+
+	if keyID, ok := input.item.param("keyid"); ok {
+		if result.KeyID, ok = keyID.(string); !ok {
+			return Result{}, errSyntax
+		}
+	}
+	key, err := keys.ResolveKey(ctx, result.KeyID)
+	if err != nil {
+		return Result{}, err
+	}
+	if alg, ok := input.item.param("alg"); ok && alg != key.Algorithm {
+		return Result{}, errAlgorithm
+	}
+
+	base, err := signatureBase(input.item, func(name string) (string, error) {
+		return requestComponent(request, name)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	if err := verify(key.Algorithm, key.Key, base, signature); err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// signatureBase builds the signature base (RFC 9421 Section 2.5) of the inner list params,
+// resolving component values with value.
+func signatureBase(params item, value func(name string) (string, error)) ([]byte, error) {
+	var b strings.Builder
+	for _, component := range params.value.([]item) {
+		name, ok := component.value.(string)
+		if !ok || len(component.params) > 0 {
+			return nil, fmt.Errorf("httpsig: unsupported component %s", serializeItem(component))
+		}
+		v, err := value(name)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(serializeItem(component))
+		b.WriteString(": ")
+		b.WriteString(v)
+		b.WriteByte('\n')
+	}
+	b.WriteString(`"@signature-params": `)
+	b.WriteString(serializeItem(params))
+	return []byte(b.String()), nil
+}
+
+// requestComponent returns the value of a request component.
+func requestComponent(request *events.APIGatewayProxyRequest, name string) (string, error) {
+	switch name {
+	case "@method":
+		return request.HTTPMethod, nil
+	case "@authority":
+		authority := strings.Join(header.Request(request).Values("Host"), "")
+		if authority == "" {
+			authority = request.RequestContext.DomainName
+		}
+		return strings.ToLower(authority), nil
+	case "@path":
+		if request.Path == "" {
+			return "/", nil
+		}
+		return request.Path, nil
+	case "@scheme":
+		if proto := strings.Join(header.Request(request).Values("X-Forwarded-Proto"), ""); proto != "" {
+			return strings.ToLower(proto), nil
+		}
+		return "https", nil
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("httpsig: unsupported component %q", name)
+	}
+	return fieldValue(header.Request(request).Values(name), name)
+}
+
+// responseComponent returns the value of a response component.
+func responseComponent(response *events.APIGatewayProxyResponse, name string) (string, error) {
+	if name == "@status" {
+		return strconv.Itoa(response.StatusCode), nil
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", fmt.Errorf("httpsig: unsupported component %q", name)
+	}
+	return fieldValue(header.Response(response).Values(name), name)
+}
+
+// fieldValue combines the values of a header field as specified by RFC 9421 Section 2.1.
+func fieldValue(values []string, name string) (string, error) {
+	trimmed := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	if len(trimmed) == 0 {
+		return "", fmt.Errorf("httpsig: missing header field %q", name)
+	}
+	return strings.Join(trimmed, ", "), nil
+}
+
+// decodeBody returns the raw bytes of a body.
+func decodeBody(body string, isBase64Encoded bool) ([]byte, error) {
+	if isBase64Encoded {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,372 @@
+package httpsig
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the fixed current time used in tests.
+var now = time.Unix(1618884480, 0)
+
+// rfcRequest returns the example request of RFC 9421 Appendix B.2, signed as in B.2.6 (Ed25519).
+func rfcRequest() events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/foo",
+		QueryStringParameters: map[string]string{
+			"param": "Value",
+			"Pet":   "dog",
+		},
+		Headers: map[string]string{
+			"Host":            "example.com",
+			"Date":            "Tue, 20 Apr 2021 02:07:55 GMT",
+			"Content-Type":    "application/json",
+			"Content-Digest":  digestSHA512,
+			"Content-Length":  "18",
+			"Signature-Input": `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`,
+			"Signature":       `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`,
+		},
+		Body: digestBody,
+	}
+}
+
+// rfcKeys returns a KeyResolver serving the Ed25519 test key of RFC 9421 Appendix B.1.4.
+func rfcKeys(t *testing.T) KeyResolver {
+	der, _ := base64.StdEncoding.DecodeString("MCowBQYDK2VwAyEAJrQLj5P/89iXES9+vFgrIy29clF9CC/oPPsw3c5D0bs=")
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return StaticKeys(map[string]Key{"test-key-ed25519": {Algorithm: Ed25519, Key: key.(ed25519.PublicKey)}})
+}
+
+// signRequest adds a signature of request under label with the given inner list.
+func signRequest(t *testing.T, request *events.APIGatewayProxyRequest, label string, params item, key Key) {
+	base, err := signatureBase(params, func(name string) (string, error) {
+		return requestComponent(request, name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := sign(key.Algorithm, key.Key, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Headers["Signature-Input"] = serializeDictionary([]member{{key: label, item: params}})
+	request.Headers["Signature"] = serializeDictionary([]member{{key: label, item: item{value: signature}}})
+}
+
+// components builds an inner list of component identifiers with the given parameters.
+func components(names []string, params ...param) item {
+	list := make([]item, len(names))
+	for i, name := range names {
+		list[i] = item{value: name}
+	}
+	return item{value: list, params: params}
+}
+
+func TestVerify_RFCExample(t *testing.T) {
+	assert := assert.New(t)
+	var result Result
+
+	handler := Verify(rfcKeys(t), WithClock(func() time.Time { return now }))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		result = ctx.Value(CtxKey{}).(Result)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), rfcRequest())
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(Result{
+		Label:      "sig-b26",
+		KeyID:      "test-key-ed25519",
+		Components: []string{"date", "@method", "@path", "@authority", "content-type", "content-length"},
+		Created:    time.Unix(1618884473, 0),
+	}, result)
+
+	// Any change to a covered component invalidates the signature
+	request := rfcRequest()
+	request.Headers["Content-Length"] = "19"
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(defaultErrorBody, response.Body)
+	assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+}
+
+func TestVerify(t *testing.T) {
+	key := Key{Algorithm: ECDSAP256SHA256, Key: p256Key}
+	keys := StaticKeys(map[string]Key{
+		"partner": {Algorithm: ECDSAP256SHA256, Key: &p256Key.PublicKey},
+		"hmac":    {Algorithm: HMACSHA256, Key: hmacSecret},
+	})
+	covered := []string{"@method", "@authority", "@path", "content-digest"}
+	created := param{key: "created", value: now.Unix()}
+	keyID := param{key: "keyid", value: "partner"}
+
+	tests := []struct {
+		name     string
+		prepare  func(request *events.APIGatewayProxyRequest)
+		opts     []Option
+		expected bool
+	}{
+		{
+			name: "Valid signature",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, created, keyID, param{key: "alg", value: ECDSAP256SHA256}), key)
+			},
+			expected: true,
+		},
+		{
+			name: "Authority from the request context",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				delete(r.Headers, "Host")
+				r.RequestContext.DomainName = "API.example.com"
+				signRequest(t, r, "sig1", components(covered, created, keyID), key)
+			},
+			expected: true,
+		},
+		{
+			name: "Required component not covered",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components([]string{"@method", "@path"}, created, keyID), key)
+			},
+			expected: false,
+		},
+		{
+			name: "Additional required component",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, created, keyID), key)
+			},
+			opts:     []Option{WithComponents("@method", "content-digest", "x-tenant")},
+			expected: false,
+		},
+		{
+			name: "Covered header missing",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				r.Headers["X-Tenant"] = "acme"
+				signRequest(t, r, "sig1", components(append(covered, "x-tenant"), created, keyID), key)
+				delete(r.Headers, "X-Tenant")
+			},
+			expected: false,
+		},
+		{
+			name: "Unsupported component",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				r.Headers["Signature-Input"] = `sig1=("@method" "@authority" "@path" "@query");keyid="partner"`
+				r.Headers["Signature"] = `sig1=:AAAA:`
+			},
+			expected: false,
+		},
+		{
+			name: "Expired",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, created, keyID, param{key: "expires", value: now.Unix()}), key)
+			},
+			expected: false,
+		},
+		{
+			name: "Too old",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, param{key: "created", value: now.Add(-10 * time.Minute).Unix()}, keyID), key)
+			},
+			opts:     []Option{WithMaxAge(5 * time.Minute)},
+			expected: false,
+		},
+		{
+			name: "Missing created with max age",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, keyID), key)
+			},
+			opts:     []Option{WithMaxAge(5 * time.Minute)},
+			expected: false,
+		},
+		{
+			name: "Algorithm mismatch",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, created, keyID, param{key: "alg", value: Ed25519}), key)
+			},
+			expected: false,
+		},
+		{
+			name: "Unknown key",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, created, param{key: "keyid", value: "unknown"}), key)
+			},
+			expected: false,
+		},
+		{
+			name: "Signed with another key",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig1", components(covered, created, param{key: "keyid", value: "hmac"}), Key{Algorithm: HMACSHA256, Key: []byte("other")})
+			},
+			expected: false,
+		},
+		{
+			name: "Second signature is valid",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig2", components(covered, created, keyID), key)
+				r.Headers["Signature-Input"] = `sig1=("@method");keyid="partner", ` + r.Headers["Signature-Input"]
+				r.Headers["Signature"] = `sig1=:AAAA:, ` + r.Headers["Signature"]
+			},
+			expected: true,
+		},
+		{
+			name: "Label mismatch",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				signRequest(t, r, "sig2", components(covered, created, keyID), key)
+			},
+			opts:     []Option{WithLabel("sig1")},
+			expected: false,
+		},
+		{
+			name:     "Unsigned",
+			prepare:  func(r *events.APIGatewayProxyRequest) {},
+			expected: false,
+		},
+		{
+			name: "Malformed header",
+			prepare: func(r *events.APIGatewayProxyRequest) {
+				r.Headers["Signature-Input"] = `sig1=("@method"`
+				r.Headers["Signature"] = `sig1=:AAAA:`
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			request := events.APIGatewayProxyRequest{
+				HTTPMethod: http.MethodPost,
+				Path:       "/orders",
+				Headers: map[string]string{
+					"Host":           "api.example.com",
+					"Content-Digest": digestSHA256,
+				},
+				Body: digestBody,
+			}
+			tt.prepare(&request)
+
+			nextCalled := false
+			opts := append([]Option{WithClock(func() time.Time { return now })}, tt.opts...)
+			handler := Verify(keys, opts...)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				nextCalled = true
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), request)
+			assert.NoError(err)
+			assert.Equal(tt.expected, nextCalled)
+			if !tt.expected {
+				assert.Equal(http.StatusUnauthorized, response.StatusCode)
+			}
+		})
+	}
+}
+
+func TestVerify_Options(t *testing.T) {
+	assert := assert.New(t)
+	type resultKey struct{}
+	request := rfcRequest()
+
+	handler := Verify(rfcKeys(t),
+		WithCtxKey(resultKey{}),
+		WithLabel("sig-b26"),
+		WithComponents("@method", "@path", "date"),
+		WithClock(func() time.Time { return now }),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Equal("test-key-ed25519", ctx.Value(resultKey{}).(Result).KeyID)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+	response, err := handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	handler = Verify(rfcKeys(t), WithComponents("content-digest"), WithResponse("application/json", `{"error":"invalid signature"}`))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+	response, err = handler(context.Background(), request)
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`{"error":"invalid signature"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestSignResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := SignResponse("our-key", Key{Algorithm: Ed25519, Key: edKey}, WithClock(func() time.Time { return now }))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode:        http.StatusOK,
+			MultiValueHeaders: map[string][]string{"content-type": {"application/json"}},
+			Body:              base64.StdEncoding.EncodeToString([]byte(digestBody)),
+			IsBase64Encoded:   true,
+		}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(digestSHA256, response.Headers["Content-Digest"])
+	assert.Equal(`sig1=("@status" "content-type" "content-digest");created=1618884480;keyid="our-key";alg="ed25519"`, response.Headers["Signature-Input"])
+
+	// The signature can be verified by the recipient
+	inputs, err := parseDictionary(response.Headers["Signature-Input"])
+	assert.NoError(err)
+	signatures, err := parseDictionary(response.Headers["Signature"])
+	assert.NoError(err)
+	base, err := signatureBase(inputs[0].item, func(name string) (string, error) {
+		return responseComponent(&response, name)
+	})
+	assert.NoError(err)
+	assert.Equal(`"@status": 200
+"content-type": application/json
+"content-digest": `+digestSHA256+`
+"@signature-params": ("@status" "content-type" "content-digest");created=1618884480;keyid="our-key";alg="ed25519"`, string(base))
+	assert.NoError(verify(Ed25519, ed25519Public, base, signatures[0].item.value.([]byte)))
+}
+
+func TestSignResponse_Options(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := SignResponse("our-key", Key{Algorithm: HMACSHA256, Key: hmacSecret},
+		WithLabel("resp"),
+		WithComponents("@status", "content-digest", "x-missing"),
+		WithClock(func() time.Time { return now }),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusCreated,
+			Headers:    map[string]string{"Content-Digest": digestSHA512},
+			Body:       digestBody,
+		}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	// An existing Content-Digest is kept, and absent header fields are not covered
+	assert.Equal(digestSHA512, response.Headers["Content-Digest"])
+	assert.Equal(`resp=("@status" "content-digest");created=1618884480;keyid="our-key";alg="hmac-sha256"`, response.Headers["Signature-Input"])
+	assert.Contains(response.Headers["Signature"], "resp=:")
+
+	// Handler errors are returned as is
+	handlerErr := errors.New("handler error")
+	_, err = SignResponse("our-key", Key{Algorithm: HMACSHA256, Key: hmacSecret})(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, handlerErr
+	})(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorIs(err, handlerErr)
+
+	// Signing errors are returned
+	_, err = SignResponse("our-key", Key{Algorithm: Ed25519, Key: hmacSecret})(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorIs(err, errKeyType)
+}
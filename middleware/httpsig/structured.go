@@ -0,0 +1,306 @@
+package httpsig
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file implements the subset of Structured Field Values (RFC 8941) used by
+// Signature-Input, Signature and Content-Digest: dictionaries whose members are
+// items or inner lists, with parameters. Decimals are not supported.
+
+// token is a Structured Field token, distinguished from a string.
+type token string
+
+// param is a parameter of an item or an inner list.
+type param struct {
+	key   string
+	value any
+}
+
+// item is a bare item or an inner list ([]item), with its parameters.
+type item struct {
+	value  any
+	params []param
+}
+
+// member is a member of a dictionary.
+type member struct {
+	key  string
+	item item
+}
+
+// param returns the value of the named parameter.
+func (it item) param(key string) (any, bool) {
+	for _, p := range it.params {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+// errSyntax is returned for malformed structured fields.
+var errSyntax = errors.New("httpsig: invalid structured field")
+
+// parser is a Structured Field parser over s.
+type parser struct {
+	s string
+}
+
+// parseDictionary parses a Structured Field dictionary.
+func parseDictionary(s string) ([]member, error) {
+	p := &parser{s: strings.TrimSpace(s)}
+	var members []member
+	for p.s != "" {
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var it item
+		if p.consume('=') {
+			if it, err = p.itemOrInnerList(); err != nil {
+				return nil, err
+			}
+		} else {
+			it.value = true
+			if it.params, err = p.params(); err != nil {
+				return nil, err
+			}
+		}
+		members = append(members, member{key: key, item: it})
+
+		p.skipOWS()
+		if p.s == "" {
+			break
+		}
+		if !p.consume(',') {
+			return nil, errSyntax
+		}
+		p.skipOWS()
+		if p.s == "" {
+			return nil, errSyntax
+		}
+	}
+	return members, nil
+}
+
+// consume removes c from the input if it is the next character.
+func (p *parser) consume(c byte) bool {
+	if p.s != "" && p.s[0] == c {
+		p.s = p.s[1:]
+		return true
+	}
+	return false
+}
+
+// skipOWS removes leading spaces and tabs.
+func (p *parser) skipOWS() {
+	p.s = strings.TrimLeft(p.s, " \t")
+}
+
+// key parses a dictionary or parameter key.
+func (p *parser) key() (string, error) {
+	if p.s == "" || !(isLower(p.s[0]) || p.s[0] == '*') {
+		return "", errSyntax
+	}
+	i := 1
+	for i < len(p.s) && (isLower(p.s[i]) || isDigit(p.s[i]) || strings.IndexByte("_-.*", p.s[i]) >= 0) {
+		i++
+	}
+	key := p.s[:i]
+	p.s = p.s[i:]
+	return key, nil
+}
+
+// itemOrInnerList parses an item or an inner list, with its parameters.
+func (p *parser) itemOrInnerList() (item, error) {
+	var it item
+	var err error
+	if p.consume('(') {
+		var list []item
+		for {
+			p.s = strings.TrimLeft(p.s, " ")
+			if p.consume(')') {
+				break
+			}
+			var inner item
+			if inner.value, err = p.bareItem(); err != nil {
+				return it, err
+			}
+			if inner.params, err = p.params(); err != nil {
+				return it, err
+			}
+			list = append(list, inner)
+			if p.s == "" || (p.s[0] != ' ' && p.s[0] != ')') {
+				return it, errSyntax
+			}
+		}
+		it.value = list
+	} else if it.value, err = p.bareItem(); err != nil {
+		return it, err
+	}
+	it.params, err = p.params()
+	return it, err
+}
+
+// params parses the parameters following an item.
+func (p *parser) params() ([]param, error) {
+	var params []param
+	for p.consume(';') {
+		p.s = strings.TrimLeft(p.s, " ")
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var value any = true
+		if p.consume('=') {
+			if value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, param{key: key, value: value})
+	}
+	return params, nil
+}
+
+// bareItem parses an integer, string, token, byte sequence or boolean.
+func (p *parser) bareItem() (any, error) {
+	if p.s == "" {
+		return nil, errSyntax
+	}
+	switch c := p.s[0]; {
+	case c == '-' || isDigit(c):
+		i := 1
+		for i < len(p.s) && isDigit(p.s[i]) {
+			i++
+		}
+		n, err := strconv.ParseInt(p.s[:i], 10, 64)
+		if err != nil || i > 16 {
+			return nil, errSyntax
+		}
+		p.s = p.s[i:]
+		return n, nil
+	case c == '"':
+		var b strings.Builder
+		for i := 1; i < len(p.s); i++ {
+			switch p.s[i] {
+			case '\\':
+				i++
+				if i == len(p.s) || (p.s[i] != '"' && p.s[i] != '\\') {
+					return nil, errSyntax
+				}
+				b.WriteByte(p.s[i])
+			case '"':
+				p.s = p.s[i+1:]
+				return b.String(), nil
+			default:
+				if p.s[i] < 0x20 || p.s[i] > 0x7e {
+					return nil, errSyntax
+				}
+				b.WriteByte(p.s[i])
+			}
+		}
+		return nil, errSyntax
+	case c == ':':
+		end := strings.IndexByte(p.s[1:], ':')
+		if end < 0 {
+			return nil, errSyntax
+		}
+		b, err := base64.StdEncoding.DecodeString(p.s[1 : end+1])
+		if err != nil {
+			return nil, errSyntax
+		}
+		p.s = p.s[end+2:]
+		return b, nil
+	case c == '?':
+		if len(p.s) < 2 || (p.s[1] != '0' && p.s[1] != '1') {
+			return nil, errSyntax
+		}
+		v := p.s[1] == '1'
+		p.s = p.s[2:]
+		return v, nil
+	case isAlpha(c) || c == '*':
+		i := 1
+		for i < len(p.s) && (isTChar(p.s[i]) || p.s[i] == ':' || p.s[i] == '/') {
+			i++
+		}
+		t := token(p.s[:i])
+		p.s = p.s[i:]
+		return t, nil
+	}
+	return nil, errSyntax
+}
+
+// serializeItem serializes an item or an inner list, with its parameters.
+func serializeItem(it item) string {
+	var b strings.Builder
+	if list, ok := it.value.([]item); ok {
+		b.WriteByte('(')
+		for i, inner := range list {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(serializeItem(inner))
+		}
+		b.WriteByte(')')
+	} else {
+		b.WriteString(serializeBareItem(it.value))
+	}
+	for _, p := range it.params {
+		b.WriteByte(';')
+		b.WriteString(p.key)
+		if v, ok := p.value.(bool); ok && v {
+			continue
+		}
+		b.WriteByte('=')
+		b.WriteString(serializeBareItem(p.value))
+	}
+	return b.String()
+}
+
+// serializeBareItem serializes an integer, string, token, byte sequence or boolean.
+func serializeBareItem(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	case token:
+		return string(v)
+	case []byte:
+		return ":" + base64.StdEncoding.EncodeToString(v) + ":"
+	case bool:
+		if v {
+			return "?1"
+		}
+		return "?0"
+	}
+	panic(fmt.Sprintf("httpsig: cannot serialize %T", v))
+}
+
+// serializeDictionary serializes a dictionary.
+func serializeDictionary(members []member) string {
+	parts := make([]string, len(members))
+	for i, m := range members {
+		parts[i] = m.key + "=" + serializeItem(m.item)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// isLower reports whether c is a lowercase ASCII letter.
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+
+// isDigit reports whether c is an ASCII digit.
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// isAlpha reports whether c is an ASCII letter.
+func isAlpha(c byte) bool { return isLower(c) || (c >= 'A' && c <= 'Z') }
+
+// isTChar reports whether c is a token character (RFC 9110).
+func isTChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,73 @@
+package httpsig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDictionary(t *testing.T) {
+	assert := assert.New(t)
+
+	members, err := parseDictionary(`sig1=("@method" "content-digest");created=1618884473;keyid="test-key";tag=app, sig2=:AQID:, flag;x=?0, n=-12`)
+	assert.NoError(err)
+	assert.Equal([]member{
+		{key: "sig1", item: item{
+			value: []item{{value: "@method"}, {value: "content-digest"}},
+			params: []param{
+				{key: "created", value: int64(1618884473)},
+				{key: "keyid", value: "test-key"},
+				{key: "tag", value: token("app")},
+			},
+		}},
+		{key: "sig2", item: item{value: []byte{1, 2, 3}}},
+		{key: "flag", item: item{value: true, params: []param{{key: "x", value: false}}}},
+		{key: "n", item: item{value: int64(-12)}},
+	}, members)
+
+	// Escapes and empty inner lists
+	members, err = parseDictionary(`a=("x\"y" "z\\"), b=()`)
+	assert.NoError(err)
+	assert.Equal([]item{{value: `x"y`}, {value: `z\`}}, members[0].item.value)
+	assert.Empty(members[1].item.value)
+
+	members, err = parseDictionary("")
+	assert.NoError(err)
+	assert.Empty(members)
+
+	for _, invalid := range []string{
+		`Sig=1`,
+		`a=1,`,
+		`a=1 b=2`,
+		`a=("x"`,
+		`a=("x""y")`,
+		`a=:not base64:`,
+		`a="unterminated`,
+		`a=?2`,
+		`a=12345678901234567`,
+	} {
+		_, err := parseDictionary(invalid)
+		assert.ErrorIs(err, errSyntax, invalid)
+	}
+}
+
+func TestSerializeItem(t *testing.T) {
+	it := item{
+		value: []item{{value: "@method"}, {value: "@path"}},
+		params: []param{
+			{key: "created", value: int64(1618884473)},
+			{key: "keyid", value: `key "1"`},
+			{key: "alg", value: token("ed25519")},
+			{key: "flag", value: true},
+		},
+	}
+	serialized := serializeItem(it)
+	assert.Equal(t, `("@method" "@path");created=1618884473;keyid="key \"1\"";alg=ed25519;flag`, serialized)
+
+	// Round trip
+	members, err := parseDictionary("sig=" + serialized)
+	assert.NoError(t, err)
+	assert.Equal(t, it, members[0].item)
+
+	assert.Equal(t, "a=:AQI=:, b=?0", serializeDictionary([]member{{key: "a", item: item{value: []byte{1, 2}}}, {key: "b", item: item{value: false}}}))
+}
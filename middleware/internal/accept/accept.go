@@ -0,0 +1,108 @@
+// Package accept parses Accept-style header lists and matches media types against media ranges.
+package accept
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Range is an element of an Accept* header.
+type Range struct {
+	// Value is the media range, coding, language range or charset, in lowercase.
+	Value string
+	// Params holds the media type parameters other than q, with lowercase names.
+	Params map[string]string
+	// Q is the quality value, between 0 and 1.
+	Q float64
+}
+
+// ParseMediaRanges parses an Accept header. Invalid elements are skipped.
+func ParseMediaRanges(header string) []Range {
+	var ranges []Range
+	for element := range strings.SplitSeq(header, ",") {
+		element = strings.TrimSpace(element)
+		if element == "" {
+			continue
+		}
+		// "*" is sent by some clients as a shorthand for "*/*"
+		if element == "*" || strings.HasPrefix(element, "*;") {
+			element = "*/*" + element[1:]
+		}
+		mediaType, params, err := mime.ParseMediaType(element)
+		if err != nil || !strings.Contains(mediaType, "/") {
+			continue
+		}
+		r := Range{Value: mediaType, Q: 1}
+		for k, v := range params {
+			if k == "q" {
+				r.Q = ParseQ(v)
+				continue
+			}
+			if r.Params == nil {
+				r.Params = make(map[string]string)
+			}
+			r.Params[k] = v
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// ParseTokens parses an Accept-Encoding, Accept-Language or Accept-Charset header.
+// Parameters other than q are ignored. Invalid elements are skipped.
+func ParseTokens(header string) []Range {
+	var ranges []Range
+	for element := range strings.SplitSeq(header, ",") {
+		value, params, _ := strings.Cut(element, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || strings.ContainsAny(value, " \t") {
+			continue
+		}
+		r := Range{Value: value, Q: 1}
+		for param := range strings.SplitSeq(params, ";") {
+			name, v, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "q") {
+				r.Q = ParseQ(strings.TrimSpace(v))
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// ParseQ parses a quality value. Invalid values are treated as 0 so that the element is ignored.
+func ParseQ(s string) float64 {
+	q, err := strconv.ParseFloat(s, 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0
+	}
+	return q
+}
+
+// MatchMediaType reports whether the lowercase media type (e.g. "application/vnd.api+json") matches
+// the lowercase media range pattern. The pattern may use "*" as the type or subtype, or "*+suffix"
+// as the subtype to match a structured syntax suffix, which requires a non-empty name before the suffix.
+func MatchMediaType(pattern, mediaType string) bool {
+	pType, pSubtype, ok := strings.Cut(pattern, "/")
+	if !ok {
+		return false
+	}
+	mType, mSubtype, ok := strings.Cut(mediaType, "/")
+	if !ok {
+		return false
+	}
+
+	if pType != "*" && pType != mType {
+		return false
+	}
+	switch {
+	case pSubtype == "*":
+		return true
+	case strings.HasPrefix(pSubtype, "*+"):
+		suffix := pSubtype[1:]
+		return len(mSubtype) > len(suffix) && strings.HasSuffix(mSubtype, suffix)
+	default:
+		return pSubtype == mSubtype
+	}
+}
@@ -0,0 +1,87 @@
+package accept
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMediaRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []Range
+	}{
+		{name: "empty", header: "", want: nil},
+		{
+			name:   "q-values and parameters",
+			header: "Text/HTML, application/json;q=0.9, text/csv; header=present; q=0.5",
+			want: []Range{
+				{Value: "text/html", Q: 1},
+				{Value: "application/json", Q: 0.9},
+				{Value: "text/csv", Params: map[string]string{"header": "present"}, Q: 0.5},
+			},
+		},
+		{
+			name:   "asterisk shorthand",
+			header: "*; q=0.2",
+			want:   []Range{{Value: "*/*", Q: 0.2}},
+		},
+		{
+			name:   "invalid elements are skipped",
+			header: "json, application/xml, text/plain;q=abc, ,",
+			want: []Range{
+				{Value: "application/xml", Q: 1},
+				{Value: "text/plain", Q: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseMediaRanges(tt.header))
+		})
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	assert.Equal(t, []Range{
+		{Value: "en-us", Q: 1},
+		{Value: "en", Q: 0.8},
+		{Value: "*", Q: 0.1},
+		{Value: "fr", Q: 0},
+	}, ParseTokens("en-US, en;q=0.8, *;q=0.1, fr;q=2, bad value"))
+	assert.Equal(t, []Range{{Value: "gzip", Q: 0.3}}, ParseTokens(" GZIP ; Q=0.3 , ,"))
+}
+
+func TestParseQ(t *testing.T) {
+	assert.Equal(t, 0.5, ParseQ("0.5"))
+	assert.Equal(t, 1.0, ParseQ("1"))
+	assert.Equal(t, 0.0, ParseQ("1.5"))
+	assert.Equal(t, 0.0, ParseQ("-1"))
+	assert.Equal(t, 0.0, ParseQ("abc"))
+}
+
+func TestMatchMediaType(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		mediaType string
+		want      bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/json", "application/xml", false},
+		{"text/*", "text/html", true},
+		{"text/*", "application/json", false},
+		{"*/*", "image/png", true},
+		{"application/*+json", "application/vnd.api+json", true},
+		{"*/*+json", "application/ld+json", true},
+		{"application/*+json", "application/json", false},
+		// The suffix needs a name before it
+		{"application/*+json", "application/+json", false},
+		{"application/*+json", "text/vnd.api+json", false},
+		{"json", "application/json", false},
+		{"application/json", "json", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchMediaType(tt.pattern, tt.mediaType), "%s %s", tt.pattern, tt.mediaType)
+	}
+}
@@ -0,0 +1,38 @@
+// Package route matches requests against the method and resource conditions of per-route middleware rules.
+package route
+
+import (
+	"path"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Match reports whether the request matches methods and the resource pattern.
+// Empty conditions match any request.
+func Match(request *events.APIGatewayProxyRequest, methods []string, resource string) bool {
+	return Method(methods, request.HTTPMethod) && (resource == "" || Resource(resource, request.Resource))
+}
+
+// Method reports whether method is one of methods, ignoring case. An empty list matches any method.
+func Method(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resource reports whether the API Gateway resource (e.g. "/users/{id}") matches pattern.
+// The pattern syntax is that of path.Match. A pattern ending with "/**" matches every resource under the prefix.
+func Resource(pattern, resource string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return resource == prefix || strings.HasPrefix(resource, prefix+"/")
+	}
+	matched, err := path.Match(pattern, resource)
+	return err == nil && matched
+}
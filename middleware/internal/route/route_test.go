@@ -0,0 +1,43 @@
+package route
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	request := &events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Resource: "/orders/{id}"}
+	assert.True(t, Match(request, nil, ""))
+	assert.True(t, Match(request, []string{"get", "post"}, "/orders/*"))
+	assert.False(t, Match(request, []string{"GET"}, "/orders/*"))
+	assert.False(t, Match(request, []string{"POST"}, "/users/*"))
+}
+
+func TestMethod(t *testing.T) {
+	assert.True(t, Method(nil, http.MethodGet))
+	assert.True(t, Method([]string{"GET", "HEAD"}, "head"))
+	assert.False(t, Method([]string{"GET", "HEAD"}, http.MethodPost))
+}
+
+func TestResource(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		resource string
+		expected bool
+	}{
+		{"/users", "/users", true},
+		{"/users/*", "/users/{id}", true},
+		{"/users/*", "/users/{id}/orders", false},
+		{"/users/**", "/users", true},
+		{"/users/**", "/users/{id}/orders", true},
+		{"/users/**", "/usersettings", false},
+		{"/{proxy+}", "/{proxy+}", true},
+		{"[", "/users", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Resource(tt.pattern, tt.resource), "%s %s", tt.pattern, tt.resource)
+	}
+}
@@ -0,0 +1,261 @@
+package ipfilter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/route"
+)
+
+const (
+	// defaultErrorBody is the default response body when the client IP is not allowed.
+	defaultErrorBody = "Forbidden"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the default key type used to store the client IP (netip.Addr) within the context.
+type CtxKey struct{}
+
+// Rule describes the allow and deny lists applied to requests matching its conditions.
+// Empty conditions match any request.
+type Rule struct {
+	// Methods is the list of HTTP methods the rule applies to (e.g. "POST").
+	Methods []string
+	// Resource is a pattern matched against the API Gateway resource (e.g. "/admin/{proxy+}") of the request.
+	// The pattern syntax is that of path.Match. A pattern ending with "/**" matches every resource under the prefix.
+	Resource string
+	// Allow is the list of IP addresses or CIDR ranges allowed. If empty, every address not denied is allowed.
+	Allow []string
+	// Deny is the list of IP addresses or CIDR ranges denied. Deny takes precedence over Allow.
+	Deny []string
+}
+
+// lists is the parsed form of the Allow and Deny lists of a Rule.
+type lists struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// Config is the configuration for the IPFilter middleware.
+type Config struct {
+	ctxKey           any
+	global           Rule
+	rules            []Rule
+	trustedProxies   []string
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the IPFilter configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the client IP to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithAllow sets the IP addresses or CIDR ranges allowed for requests not matching any rule.
+func WithAllow(cidrs ...string) Option {
+	return func(c *Config) {
+		c.global.Allow = append(c.global.Allow, cidrs...)
+	}
+}
+
+// WithDeny sets the IP addresses or CIDR ranges denied for requests not matching any rule.
+func WithDeny(cidrs ...string) Option {
+	return func(c *Config) {
+		c.global.Deny = append(c.global.Deny, cidrs...)
+	}
+}
+
+// WithRules sets per-route rules. The first rule matching the request replaces the lists given with
+// WithAllow and WithDeny.
+func WithRules(rules ...Rule) Option {
+	return func(c *Config) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// WithTrustedProxies sets the IP addresses or CIDR ranges of the proxies trusted to append the client IP
+// to the X-Forwarded-For header. By default, no proxy is trusted and the source IP of the request is used.
+func WithTrustedProxies(cidrs ...string) Option {
+	return func(c *Config) {
+		c.trustedProxies = append(c.trustedProxies, cidrs...)
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when the client IP is not allowed.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// IPFilter creates middleware that enforces IP allow and deny lists.
+//
+// The client IP is RequestContext.Identity.SourceIP. If it is a trusted proxy (see WithTrustedProxies),
+// the X-Forwarded-For header is read from right to left and the first address that is not a trusted proxy
+// is the client IP. IPv4 and IPv6 addresses and CIDR ranges are supported.
+//
+// The lists of the first matching rule (see WithRules), or the lists given with WithAllow and WithDeny,
+// are applied: denied addresses, and addresses not allowed when an allow list is set, are rejected with
+// 403 Forbidden. Without lists, every request is passed through, so IPFilter can also be used to only
+// resolve the client IP. Invalid addresses or CIDR ranges in the configuration cause a panic.
+//
+// The client IP is set in the context under CtxKey{} (or the key given with WithCtxKey) as a netip.Addr.
+// To log it, place IPFilter before the logger and use logger.WithContextAttr.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler,
+//	    ipfilter.IPFilter(
+//	        ipfilter.WithTrustedProxies("10.0.0.0/8"),
+//	        ipfilter.WithRules(ipfilter.Rule{Resource: "/admin/**", Allow: []string{"203.0.113.0/24", "2001:db8::/32"}}),
+//	    ),
+//	    logger.StructuredLogger(logger.WithContextAttr("clientIP", ipfilter.CtxKey{})),
+//	)
+func IPFilter(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	global := parseLists(config.global)
+	rules := make([]lists, len(config.rules))
+	for i, rule := range config.rules {
+		rules[i] = parseLists(rule)
+	}
+	trustedProxies := mustParsePrefixes(config.trustedProxies)
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusForbidden,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			l := global
+			for i := range config.rules {
+				if route.Match(&request, config.rules[i].Methods, config.rules[i].Resource) {
+					l = rules[i]
+					break
+				}
+			}
+
+			ip, ok := ClientIP(request, trustedProxies)
+			if !ok {
+				if len(l.allow) > 0 || len(l.deny) > 0 {
+					return errorResponse, nil
+				}
+				return next(ctx, request)
+			}
+			if !l.allowed(ip) {
+				return errorResponse, nil
+			}
+
+			ctxWithIP := context.WithValue(ctx, config.ctxKey, ip)
+			return next(ctxWithIP, request)
+		}
+	}
+}
+
+// ClientIP returns the client IP of request. If the source IP is one of trustedProxies, the X-Forwarded-For
+// header is read from right to left and the first address that is not a trusted proxy is returned.
+// The second return value is false if the source IP is missing or invalid.
+func ClientIP(request events.APIGatewayProxyRequest, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	ip, err := netip.ParseAddr(strings.TrimSpace(request.RequestContext.Identity.SourceIP))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+
+	if !contains(trustedProxies, ip) {
+		return ip, true
+	}
+	forwarded := header.Request(&request).Values("X-Forwarded-For")
+	var hops []string
+	for _, value := range forwarded {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// The header is malformed beyond this point; use the last trusted hop
+			break
+		}
+		ip = hop.Unmap()
+		if !contains(trustedProxies, ip) {
+			break
+		}
+	}
+	return ip, true
+}
+
+// allowed reports whether ip passes the lists.
+func (l lists) allowed(ip netip.Addr) bool {
+	if contains(l.deny, ip) {
+		return false
+	}
+	return len(l.allow) == 0 || contains(l.allow, ip)
+}
+
+// parseLists parses the Allow and Deny lists of rule.
+func parseLists(rule Rule) lists {
+	return lists{
+		allow: mustParsePrefixes(rule.Allow),
+		deny:  mustParsePrefixes(rule.Deny),
+	}
+}
+
+// mustParsePrefixes parses IP addresses and CIDR ranges. A single address is a prefix of its full length.
+// It panics if an entry is invalid.
+func mustParsePrefixes(cidrs []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if strings.Contains(cidr, "/") {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				panic(fmt.Errorf("ipfilter: %w", err))
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(cidr)
+		if err != nil {
+			panic(fmt.Errorf("ipfilter: %w", err))
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return prefixes
+}
+
+// contains reports whether one of prefixes contains ip.
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
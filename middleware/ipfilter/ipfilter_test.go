@@ -0,0 +1,226 @@
+package ipfilter
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRequest creates a request from the given source IP and X-Forwarded-For header.
+func createRequest(sourceIP, forwardedFor string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Resource:   "/orders",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: sourceIP},
+		},
+	}
+	if forwardedFor != "" {
+		request.Headers = map[string]string{"X-Forwarded-For": forwardedFor}
+	}
+	return request
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := mustParsePrefixes([]string{"10.0.0.0/8", "fd00::/8"})
+
+	tests := []struct {
+		name         string
+		sourceIP     string
+		forwardedFor string
+		expected     string
+		expectOK     bool
+	}{
+		{"Source IP", "203.0.113.7", "", "203.0.113.7", true},
+		{"Untrusted source ignores X-Forwarded-For", "203.0.113.7", "198.51.100.1", "203.0.113.7", true},
+		{"Trusted proxy", "10.0.0.1", "198.51.100.1", "198.51.100.1", true},
+		{"Spoofed leftmost entry", "10.0.0.1", "1.2.3.4, 198.51.100.1", "198.51.100.1", true},
+		{"Chain of trusted proxies", "10.0.0.1", "198.51.100.1, 10.1.1.1,10.2.2.2", "198.51.100.1", true},
+		{"All hops trusted", "10.0.0.1", "10.1.1.1", "10.1.1.1", true},
+		{"Malformed hop", "10.0.0.1", "198.51.100.1, garbage, 10.1.1.1", "10.1.1.1", true},
+		{"Trusted proxy without header", "10.0.0.1", "", "10.0.0.1", true},
+		{"IPv6", "fd00::1", "2001:db8::1", "2001:db8::1", true},
+		{"IPv4-mapped IPv6", "::ffff:203.0.113.7", "", "203.0.113.7", true},
+		{"Missing source IP", "", "198.51.100.1", "", false},
+		{"Invalid source IP", "test-invoke-source-ip", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, ok := ClientIP(createRequest(tt.sourceIP, tt.forwardedFor), trusted)
+			assert.Equal(t, tt.expectOK, ok)
+			if tt.expectOK {
+				assert.Equal(t, netip.MustParseAddr(tt.expected), ip)
+			}
+		})
+	}
+
+	// Multi-value headers are read in order
+	request := createRequest("10.0.0.1", "")
+	request.MultiValueHeaders = map[string][]string{"x-forwarded-for": {"1.2.3.4", "198.51.100.1, 10.1.1.1"}}
+	ip, ok := ClientIP(request, trusted)
+	assert.True(t, ok)
+	assert.Equal(t, netip.MustParseAddr("198.51.100.1"), ip)
+}
+
+func TestIPFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		request  events.APIGatewayProxyRequest
+		expected bool
+	}{
+		{
+			name:     "No lists",
+			request:  createRequest("203.0.113.7", ""),
+			expected: true,
+		},
+		{
+			name:     "No lists with invalid source IP",
+			request:  createRequest("invalid", ""),
+			expected: true,
+		},
+		{
+			name:     "Allowed",
+			opts:     []Option{WithAllow("203.0.113.0/24", "2001:db8::/32")},
+			request:  createRequest("203.0.113.7", ""),
+			expected: true,
+		},
+		{
+			name:     "Allowed IPv6",
+			opts:     []Option{WithAllow("203.0.113.0/24", "2001:db8::/32")},
+			request:  createRequest("2001:db8::7", ""),
+			expected: true,
+		},
+		{
+			name:     "Not allowed",
+			opts:     []Option{WithAllow("203.0.113.0/24")},
+			request:  createRequest("198.51.100.1", ""),
+			expected: false,
+		},
+		{
+			name:     "Denied single address",
+			opts:     []Option{WithDeny("203.0.113.7")},
+			request:  createRequest("203.0.113.7", ""),
+			expected: false,
+		},
+		{
+			name:     "Deny takes precedence",
+			opts:     []Option{WithAllow("203.0.113.0/24"), WithDeny("203.0.113.7/32")},
+			request:  createRequest("203.0.113.7", ""),
+			expected: false,
+		},
+		{
+			name:     "Invalid source IP with lists",
+			opts:     []Option{WithDeny("198.51.100.0/24")},
+			request:  createRequest("invalid", ""),
+			expected: false,
+		},
+		{
+			name:     "Client IP behind trusted proxy",
+			opts:     []Option{WithTrustedProxies("10.0.0.0/8"), WithAllow("198.51.100.0/24")},
+			request:  createRequest("10.0.0.1", "198.51.100.1"),
+			expected: true,
+		},
+		{
+			name:     "X-Forwarded-For from untrusted source",
+			opts:     []Option{WithAllow("198.51.100.0/24")},
+			request:  createRequest("203.0.113.7", "198.51.100.1"),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			nextCalled := false
+			handler := IPFilter(tt.opts...)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				nextCalled = true
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), tt.request)
+			assert.NoError(err)
+			assert.Equal(tt.expected, nextCalled)
+			if !tt.expected {
+				assert.Equal(http.StatusForbidden, response.StatusCode)
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestIPFilter_Rules(t *testing.T) {
+	handler := IPFilter(
+		WithDeny("192.0.2.0/24"),
+		WithRules(
+			Rule{Resource: "/admin/**", Allow: []string{"203.0.113.0/24"}},
+			Rule{Methods: []string{"DELETE"}, Allow: []string{"203.0.113.7"}},
+		),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		resource string
+		sourceIP string
+		expected int
+	}{
+		{"Admin route allowed", http.MethodGet, "/admin/users", "203.0.113.8", http.StatusOK},
+		{"Admin route not allowed", http.MethodGet, "/admin", "198.51.100.1", http.StatusForbidden},
+		{"Rule replaces global deny list", http.MethodGet, "/admin/users", "192.0.2.1", http.StatusForbidden},
+		{"Delete allowed", http.MethodDelete, "/orders", "203.0.113.7", http.StatusOK},
+		{"Delete not allowed", http.MethodDelete, "/orders", "203.0.113.8", http.StatusForbidden},
+		{"Global deny list", http.MethodGet, "/orders", "192.0.2.1", http.StatusForbidden},
+		{"Global lists allow", http.MethodGet, "/orders", "198.51.100.1", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := createRequest(tt.sourceIP, "")
+			request.HTTPMethod = tt.method
+			request.Resource = tt.resource
+			response, err := handler(context.Background(), request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, response.StatusCode)
+		})
+	}
+}
+
+func TestIPFilter_Options(t *testing.T) {
+	assert := assert.New(t)
+	type ipKey struct{}
+	var clientIP any
+
+	handler := IPFilter(
+		WithCtxKey(ipKey{}),
+		WithDeny("192.0.2.0/24"),
+		WithResponse("application/json", `{"error":"forbidden"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		clientIP = ctx.Value(ipKey{})
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), createRequest("203.0.113.7", ""))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(netip.MustParseAddr("203.0.113.7"), clientIP)
+
+	response, err = handler(context.Background(), createRequest("192.0.2.1", ""))
+	assert.NoError(err)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+	assert.Equal(`{"error":"forbidden"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+
+	// Invalid configuration
+	assert.Panics(func() { IPFilter(WithAllow("203.0.113.0/33")) })
+	assert.Panics(func() { IPFilter(WithTrustedProxies("proxy")) })
+	assert.Panics(func() { IPFilter(WithRules(Rule{Deny: []string{"1.2.3"}})) })
+}
@@ -0,0 +1,249 @@
+package limits
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultMaxBodyBytes is the default maximum size of the decoded request body.
+	defaultMaxBodyBytes = 1 << 20
+
+	// defaultMaxHeaders is the default maximum number of request header values.
+	defaultMaxHeaders = 100
+
+	// defaultMaxHeaderBytes is the default maximum total size of the request headers.
+	defaultMaxHeaderBytes = 10 << 10
+
+	// defaultMaxQueryParams is the default maximum number of query parameter values.
+	defaultMaxQueryParams = 100
+
+	// defaultMaxURLLength is the default maximum length of the request path and query string.
+	defaultMaxURLLength = 8 << 10
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// response is the Content-Type and body of an error response.
+type response struct {
+	contentType string
+	body        string
+}
+
+// Config is the configuration for the Limits middleware.
+type Config struct {
+	maxBodyBytes    int64
+	maxHeaders      int
+	maxHeaderBytes  int
+	maxQueryParams  int
+	maxURLLength    int
+	bodyTooLarge    response
+	headersTooLarge response
+	uriTooLong      response
+}
+
+// Option is a function type to modify the Limits configuration.
+type Option func(*Config)
+
+// WithMaxBodyBytes sets the maximum size of the request body in bytes, measured after base64 decoding.
+// The default is 1 MiB. Zero disables the limit.
+func WithMaxBodyBytes(n int64) Option {
+	return func(c *Config) {
+		c.maxBodyBytes = n
+	}
+}
+
+// WithMaxHeaders sets the maximum number of request header values. The default is 100. Zero disables the limit.
+func WithMaxHeaders(n int) Option {
+	return func(c *Config) {
+		c.maxHeaders = n
+	}
+}
+
+// WithMaxHeaderBytes sets the maximum total size of the request headers in bytes, counting the names and values.
+// The default is 10 KiB. Zero disables the limit.
+func WithMaxHeaderBytes(n int) Option {
+	return func(c *Config) {
+		c.maxHeaderBytes = n
+	}
+}
+
+// WithMaxQueryParams sets the maximum number of query parameter values. The default is 100. Zero disables the limit.
+func WithMaxQueryParams(n int) Option {
+	return func(c *Config) {
+		c.maxQueryParams = n
+	}
+}
+
+// WithMaxURLLength sets the maximum length of the request path and query string.
+// The default is 8 KiB. Zero disables the limit.
+func WithMaxURLLength(n int) Option {
+	return func(c *Config) {
+		c.maxURLLength = n
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when any limit is exceeded.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.bodyTooLarge = response{contentType: contentType, body: body}
+		c.headersTooLarge = response{contentType: contentType, body: body}
+		c.uriTooLong = response{contentType: contentType, body: body}
+	}
+}
+
+// WithBodyTooLargeResponse sets the response Content-Type header and response body returned
+// when the body is too large (413).
+func WithBodyTooLargeResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.bodyTooLarge = response{contentType: contentType, body: body}
+	}
+}
+
+// WithHeadersTooLargeResponse sets the response Content-Type header and response body returned
+// when there are too many headers or they are too large (431).
+func WithHeadersTooLargeResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.headersTooLarge = response{contentType: contentType, body: body}
+	}
+}
+
+// WithURITooLongResponse sets the response Content-Type header and response body returned
+// when there are too many query parameters or the URL is too long (414).
+func WithURITooLongResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.uriTooLong = response{contentType: contentType, body: body}
+	}
+}
+
+// Limits creates middleware that rejects oversized requests before they reach the handler.
+//
+// The limits are checked in the following order:
+//   - The number of query parameter values and the length of the path and query string.
+//     Requests exceeding them receive 414 URI Too Long.
+//   - The number of header values and the total size of the header names and values.
+//     Requests exceeding them receive 431 Request Header Fields Too Large.
+//   - The size of the body, after base64 decoding if the body is base64-encoded.
+//     Requests exceeding it receive 413 Content Too Large.
+//
+// The sizes are computed without decoding the body, so Limits is cheap to place before middleware
+// such as validate.Validate or decompress.Decompress.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, limits.Limits(limits.WithMaxBodyBytes(256<<10)), validate.Validate[MyRequest]())
+func Limits(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		maxBodyBytes:    defaultMaxBodyBytes,
+		maxHeaders:      defaultMaxHeaders,
+		maxHeaderBytes:  defaultMaxHeaderBytes,
+		maxQueryParams:  defaultMaxQueryParams,
+		maxURLLength:    defaultMaxURLLength,
+		bodyTooLarge:    response{contentType: defaultErrorContentType, body: http.StatusText(http.StatusRequestEntityTooLarge)},
+		headersTooLarge: response{contentType: defaultErrorContentType, body: http.StatusText(http.StatusRequestHeaderFieldsTooLarge)},
+		uriTooLong:      response{contentType: defaultErrorContentType, body: http.StatusText(http.StatusRequestURITooLong)},
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			query := queryValues(&request)
+			if config.maxQueryParams > 0 && countValues(query) > config.maxQueryParams {
+				return errorResponse(http.StatusRequestURITooLong, config.uriTooLong), nil
+			}
+			if config.maxURLLength > 0 && urlLength(request.Path, query) > config.maxURLLength {
+				return errorResponse(http.StatusRequestURITooLong, config.uriTooLong), nil
+			}
+
+			if config.maxHeaders > 0 || config.maxHeaderBytes > 0 {
+				h := header.Request(&request)
+				if config.maxHeaders > 0 && countValues(h) > config.maxHeaders {
+					return errorResponse(http.StatusRequestHeaderFieldsTooLarge, config.headersTooLarge), nil
+				}
+				if config.maxHeaderBytes > 0 && headerBytes(h) > config.maxHeaderBytes {
+					return errorResponse(http.StatusRequestHeaderFieldsTooLarge, config.headersTooLarge), nil
+				}
+			}
+
+			if config.maxBodyBytes > 0 && bodySize(request.Body, request.IsBase64Encoded) > config.maxBodyBytes {
+				return errorResponse(http.StatusRequestEntityTooLarge, config.bodyTooLarge), nil
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// errorResponse builds the response returned when a limit is exceeded.
+func errorResponse(statusCode int, r response) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Body:       r.body,
+		Headers:    map[string]string{"Content-Type": r.contentType},
+	}
+}
+
+// queryValues returns the query parameters of the request, from MultiValueQueryStringParameters
+// and from QueryStringParameters for names missing there.
+func queryValues(request *events.APIGatewayProxyRequest) url.Values {
+	values := make(url.Values, len(request.MultiValueQueryStringParameters))
+	for k, v := range request.MultiValueQueryStringParameters {
+		values[k] = v
+	}
+	for k, v := range request.QueryStringParameters {
+		if _, ok := values[k]; !ok {
+			values[k] = []string{v}
+		}
+	}
+	return values
+}
+
+// countValues returns the total number of values in m.
+func countValues(m map[string][]string) int {
+	n := 0
+	for _, v := range m {
+		n += len(v)
+	}
+	return n
+}
+
+// urlLength returns the length of the path and the encoded query string.
+func urlLength(path string, query url.Values) int {
+	n := len(path)
+	if len(query) > 0 {
+		n += 1 + len(query.Encode())
+	}
+	return n
+}
+
+// headerBytes returns the total size of the header names and values.
+func headerBytes(h http.Header) int {
+	n := 0
+	for k, v := range h {
+		for _, value := range v {
+			n += len(k) + len(value)
+		}
+	}
+	return n
+}
+
+// bodySize returns the size of the body after base64 decoding, without decoding it.
+func bodySize(body string, isBase64Encoded bool) int64 {
+	if !isBase64Encoded {
+		return int64(len(body))
+	}
+	body = strings.TrimRight(body, "=")
+	return int64(base64.RawStdEncoding.DecodedLen(len(body)))
+}
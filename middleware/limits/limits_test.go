@@ -0,0 +1,210 @@
+package limits
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// okHandler is a handler that returns 200 OK.
+func okHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+}
+
+// manyHeaders returns n headers.
+func manyHeaders(n int) map[string]string {
+	headers := make(map[string]string, n)
+	for i := range n {
+		headers[fmt.Sprintf("X-Header-%d", i)] = "v"
+	}
+	return headers
+}
+
+// manyParams returns n query parameters.
+func manyParams(n int) map[string]string {
+	params := make(map[string]string, n)
+	for i := range n {
+		params[fmt.Sprintf("p%d", i)] = "v"
+	}
+	return params
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []Option
+		request        events.APIGatewayProxyRequest
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "within limits",
+			request:        events.APIGatewayProxyRequest{Path: "/items", Headers: manyHeaders(10), QueryStringParameters: manyParams(10), Body: "{}"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "body too large",
+			opts:           []Option{WithMaxBodyBytes(10)},
+			request:        events.APIGatewayProxyRequest{Body: strings.Repeat("a", 11)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "Request Entity Too Large",
+		},
+		{
+			name:           "body at limit",
+			opts:           []Option{WithMaxBodyBytes(10)},
+			request:        events.APIGatewayProxyRequest{Body: strings.Repeat("a", 10)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "base64 body measured after decoding",
+			opts:           []Option{WithMaxBodyBytes(10)},
+			request:        events.APIGatewayProxyRequest{Body: base64.StdEncoding.EncodeToString(make([]byte, 10)), IsBase64Encoded: true},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "base64 body too large",
+			opts:           []Option{WithMaxBodyBytes(10)},
+			request:        events.APIGatewayProxyRequest{Body: base64.StdEncoding.EncodeToString(make([]byte, 11)), IsBase64Encoded: true},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "default body limit",
+			request:        events.APIGatewayProxyRequest{Body: strings.Repeat("a", 1<<20+1)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "body limit disabled",
+			opts:           []Option{WithMaxBodyBytes(0)},
+			request:        events.APIGatewayProxyRequest{Body: strings.Repeat("a", 1<<20+1)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "too many headers",
+			opts:           []Option{WithMaxHeaders(5)},
+			request:        events.APIGatewayProxyRequest{Headers: manyHeaders(6)},
+			expectedStatus: http.StatusRequestHeaderFieldsTooLarge,
+			expectedBody:   "Request Header Fields Too Large",
+		},
+		{
+			name: "header values are counted",
+			opts: []Option{WithMaxHeaders(2)},
+			request: events.APIGatewayProxyRequest{
+				Headers:           map[string]string{"Accept": "c"},
+				MultiValueHeaders: map[string][]string{"Accept": {"a", "b", "c"}},
+			},
+			expectedStatus: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:           "headers too large",
+			opts:           []Option{WithMaxHeaderBytes(20)},
+			request:        events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": strings.Repeat("a", 15)}},
+			expectedStatus: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:           "headers at limit",
+			opts:           []Option{WithMaxHeaderBytes(20)},
+			request:        events.APIGatewayProxyRequest{Headers: map[string]string{"Cookie": strings.Repeat("a", 14)}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "too many query parameters",
+			opts:           []Option{WithMaxQueryParams(5)},
+			request:        events.APIGatewayProxyRequest{QueryStringParameters: manyParams(6)},
+			expectedStatus: http.StatusRequestURITooLong,
+			expectedBody:   "Request URI Too Long",
+		},
+		{
+			name: "query values are counted",
+			opts: []Option{WithMaxQueryParams(2)},
+			request: events.APIGatewayProxyRequest{
+				MultiValueQueryStringParameters: map[string][]string{"id": {"1", "2", "3"}},
+			},
+			expectedStatus: http.StatusRequestURITooLong,
+		},
+		{
+			name:           "URL too long",
+			opts:           []Option{WithMaxURLLength(20)},
+			request:        events.APIGatewayProxyRequest{Path: "/items", QueryStringParameters: map[string]string{"q": "a b c d e f g"}},
+			expectedStatus: http.StatusRequestURITooLong,
+		},
+		{
+			name:           "URL at limit",
+			opts:           []Option{WithMaxURLLength(20)},
+			request:        events.APIGatewayProxyRequest{Path: "/items", QueryStringParameters: map[string]string{"q": "abcdefghijk"}},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "URI checked before headers and body",
+			opts:           []Option{WithMaxQueryParams(1), WithMaxHeaders(1), WithMaxBodyBytes(1)},
+			request:        events.APIGatewayProxyRequest{QueryStringParameters: manyParams(2), Headers: manyHeaders(2), Body: "ab"},
+			expectedStatus: http.StatusRequestURITooLong,
+		},
+		{
+			name:           "headers checked before body",
+			opts:           []Option{WithMaxHeaders(1), WithMaxBodyBytes(1)},
+			request:        events.APIGatewayProxyRequest{Headers: manyHeaders(2), Body: "ab"},
+			expectedStatus: http.StatusRequestHeaderFieldsTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			response, err := Limits(tt.opts...)(okHandler)(context.Background(), tt.request)
+			assert.NoError(err)
+			assert.Equal(tt.expectedStatus, response.StatusCode)
+			if tt.expectedBody != "" {
+				assert.Equal(tt.expectedBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestLimits_Responses(t *testing.T) {
+	assert := assert.New(t)
+	handler := Limits(
+		WithMaxBodyBytes(1), WithMaxHeaders(1), WithMaxQueryParams(1),
+		WithResponse("application/json", `{"error":"too large"}`),
+		WithURITooLongResponse("application/json", `{"error":"uri too long"}`),
+	)(okHandler)
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Body: "ab"})
+	assert.NoError(err)
+	assert.Equal(http.StatusRequestEntityTooLarge, response.StatusCode)
+	assert.Equal(`{"error":"too large"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{Headers: manyHeaders(2)})
+	assert.NoError(err)
+	assert.Equal(http.StatusRequestHeaderFieldsTooLarge, response.StatusCode)
+	assert.Equal(`{"error":"too large"}`, response.Body)
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: manyParams(2)})
+	assert.NoError(err)
+	assert.Equal(http.StatusRequestURITooLong, response.StatusCode)
+	assert.Equal(`{"error":"uri too long"}`, response.Body)
+
+	handler = Limits(
+		WithMaxBodyBytes(1), WithMaxHeaders(1),
+		WithBodyTooLargeResponse("text/plain", "body"),
+		WithHeadersTooLargeResponse("text/plain", "headers"),
+	)(okHandler)
+	response, _ = handler(context.Background(), events.APIGatewayProxyRequest{Body: "ab"})
+	assert.Equal("body", response.Body)
+	response, _ = handler(context.Background(), events.APIGatewayProxyRequest{Headers: manyHeaders(2)})
+	assert.Equal("headers", response.Body)
+}
+
+func TestBodySize(t *testing.T) {
+	for n := range 10 {
+		data := make([]byte, n)
+		assert.Equal(t, int64(n), bodySize(base64.StdEncoding.EncodeToString(data), true), "n=%d", n)
+		assert.Equal(t, int64(n), bodySize(string(data), false), "n=%d", n)
+	}
+}
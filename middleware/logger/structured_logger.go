@@ -14,6 +14,13 @@ type Config struct {
 	logger                      *slog.Logger
 	isRequestBodyLoggingEnable  bool
 	isResponseBodyLoggingEnable bool
+	contextAttrs                []contextAttr
+}
+
+// contextAttr is a context value logged as an attribute.
+type contextAttr struct {
+	name   string
+	ctxKey any
 }
 
 // Option is a function type to modify the StructuredLogger configuration.
@@ -42,6 +49,15 @@ func WithResponseBodyLogging(enable bool) Option {
 	}
 }
 
+// WithContextAttr logs the context value stored under ctxKey as an attribute with the given name,
+// such as the client IP set by ipfilter.IPFilter. The value is omitted when absent from the context.
+// Only values set by middleware placed before StructuredLogger are visible.
+func WithContextAttr(name string, ctxKey any) Option {
+	return func(c *Config) {
+		c.contextAttrs = append(c.contextAttrs, contextAttr{name: name, ctxKey: ctxKey})
+	}
+}
+
 // StructuredLogger creates middleware that logs request and response information using structured logging.
 //
 // By default, it uses slog.Default() as the logger. A custom logger can be specified using the WithLogger option.
@@ -102,10 +118,13 @@ func logRequest(ctx context.Context, config *Config, request *events.APIGatewayP
 		reqCopy.Body = "(omitted)"
 	}
 
-	config.logger.LogAttrs(ctx, slog.LevelInfo, "request received",
+	attrs := []slog.Attr{
 		slog.Any("request", reqCopy),
 		slog.Int("bodySize", bodySize),
-	)
+	}
+	attrs = appendContextAttrs(ctx, config, attrs)
+
+	config.logger.LogAttrs(ctx, slog.LevelInfo, "request received", attrs...)
 }
 
 // logResponse logs response information, error, and execution duration in a structured format.
@@ -128,6 +147,7 @@ func logResponse(
 		slog.Int("bodySize", bodySize),
 		slog.Duration("duration", duration),
 	}
+	attrs = appendContextAttrs(ctx, config, attrs)
 
 	level := slog.LevelInfo
 	message := "request processed successfully"
@@ -140,3 +160,13 @@ func logResponse(
 
 	config.logger.LogAttrs(ctx, level, message, attrs...)
 }
+
+// appendContextAttrs appends the context values configured with WithContextAttr to attrs.
+func appendContextAttrs(ctx context.Context, config *Config, attrs []slog.Attr) []slog.Attr {
+	for _, a := range config.contextAttrs {
+		if v := ctx.Value(a.ctxKey); v != nil {
+			attrs = append(attrs, slog.Any(a.name, v))
+		}
+	}
+	return attrs
+}
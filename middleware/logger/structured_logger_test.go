@@ -274,3 +274,39 @@ func TestStructuredLogger_Integration(t *testing.T) {
 		t.Errorf("Expected duration to be at least 1ms, got %v", duration)
 	}
 }
+
+func TestStructuredLogger_WithContextAttr(t *testing.T) {
+	type clientIPKey struct{}
+	type missingKey struct{}
+
+	handler := testHandler{
+		resp: events.APIGatewayProxyResponse{StatusCode: http.StatusOK},
+	}
+
+	// Setup custom logger with test handler
+	logHandler := &testLogHandler{records: []map[string]interface{}{}}
+	logger := slog.New(logHandler)
+
+	mw := StructuredLogger(
+		WithLogger(logger),
+		WithContextAttr("clientIP", clientIPKey{}),
+		WithContextAttr("missing", missingKey{}),
+	)
+	wrappedHandler := mw(handler.Handle)
+
+	ctx := context.WithValue(context.Background(), clientIPKey{}, "203.0.113.7")
+	_, _ = wrappedHandler(ctx, events.APIGatewayProxyRequest{})
+
+	// Verify logs
+	if len(logHandler.records) != 2 {
+		t.Fatalf("Expected 2 log records, got %d", len(logHandler.records))
+	}
+	for _, record := range logHandler.records {
+		if record["clientIP"] != "203.0.113.7" {
+			t.Errorf("Expected clientIP '203.0.113.7', got %v", record["clientIP"])
+		}
+		if _, ok := record["missing"]; ok {
+			t.Errorf("Expected missing context value to be omitted, got %v", record["missing"])
+		}
+	}
+}
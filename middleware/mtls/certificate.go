@@ -0,0 +1,129 @@
+package mtls
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+// errNoCertificate is returned when the PEM data holds no certificate.
+var errNoCertificate = errors.New("mtls: no certificate found")
+
+// Certificate is the parsed client certificate of a request.
+type Certificate struct {
+	// Subject is the distinguished name of the subject, in RFC 2253 form (e.g. "CN=client,O=Acme").
+	Subject string
+	// Issuer is the distinguished name of the issuer, in RFC 2253 form.
+	Issuer string
+	// SerialNumber is the serial number, in lowercase hexadecimal.
+	SerialNumber string
+	// Fingerprint is the SHA-256 hash of the DER encoding, in lowercase hexadecimal.
+	Fingerprint string
+	// DNSNames, EmailAddresses, IPAddresses and URIs are the subject alternative names.
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []string
+	URIs           []string
+	// NotBefore and NotAfter are the bounds of the validity period.
+	NotBefore time.Time
+	NotAfter  time.Time
+	// X509 is the parsed certificate.
+	X509 *x509.Certificate
+}
+
+// SANs returns all subject alternative names of the certificate.
+func (c Certificate) SANs() []string {
+	sans := make([]string, 0, len(c.DNSNames)+len(c.EmailAddresses)+len(c.IPAddresses)+len(c.URIs))
+	sans = append(sans, c.DNSNames...)
+	sans = append(sans, c.EmailAddresses...)
+	sans = append(sans, c.IPAddresses...)
+	return append(sans, c.URIs...)
+}
+
+// ParsePEM parses the first certificate of PEM encoded data.
+func ParsePEM(data string) (Certificate, error) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return Certificate{}, errNoCertificate
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Certificate{}, err
+		}
+		return newCertificate(cert), nil
+	}
+}
+
+// newCertificate builds a Certificate from a parsed X.509 certificate.
+func newCertificate(cert *x509.Certificate) Certificate {
+	fingerprint := sha256.Sum256(cert.Raw)
+	c := Certificate{
+		Subject:        cert.Subject.String(),
+		Issuer:         cert.Issuer.String(),
+		SerialNumber:   cert.SerialNumber.Text(16),
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+		X509:           cert,
+	}
+	for _, ip := range cert.IPAddresses {
+		c.IPAddresses = append(c.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		c.URIs = append(c.URIs, uri.String())
+	}
+	return c
+}
+
+// Source returns the PEM encoded client certificate of a request, or "" if there is none.
+//
+// The APIGatewayProxyRequest type does not expose requestContext.identity.clientCert, so the certificate
+// must be passed to the middleware by other means, such as a Lambda authorizer or a trusted proxy.
+type Source func(ctx context.Context, request events.APIGatewayProxyRequest) string
+
+// FromAuthorizer returns a Source reading the certificate from the named entry of the authorizer context.
+// A Lambda authorizer receives requestContext.identity.clientCert.clientCertPem and can pass it on this way.
+func FromAuthorizer(key string) Source {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		pem, _ := request.RequestContext.Authorizer[key].(string)
+		return pem
+	}
+}
+
+// FromHeader returns a Source reading the URL-encoded certificate from the named request header,
+// such as X-Amzn-Mtls-Clientcert set by an Application Load Balancer in mTLS passthrough mode.
+// Only use it behind a proxy that overwrites the header, as clients can set it otherwise.
+func FromHeader(name string) Source {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		value := header.Request(&request).Get(name)
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			return unescaped
+		}
+		return value
+	}
+}
+
+// FromContext returns a Source reading the certificate from a string stored in the context under ctxKey,
+// e.g. by a custom handler decoding the raw event.
+func FromContext(ctxKey any) Source {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		pem, _ := ctx.Value(ctxKey).(string)
+		return pem
+	}
+}
@@ -0,0 +1,109 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the fixed current time used in tests.
+var now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testCert is a self-signed client certificate used in tests.
+var testCert = func() *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	spiffe, _ := url.Parse("spiffe://acme.example/ns/billing")
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(0xabc123),
+		Subject:        pkix.Name{CommonName: "billing", Organization: []string{"Acme"}},
+		Issuer:         pkix.Name{CommonName: "Acme CA"},
+		NotBefore:      now.Add(-24 * time.Hour),
+		NotAfter:       now.Add(24 * time.Hour),
+		DNSNames:       []string{"billing.partner.example.com"},
+		EmailAddresses: []string{"ops@acme.example"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{spiffe},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	return cert
+}()
+
+// testPEM is the PEM encoding of testCert.
+var testPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testCert.Raw}))
+
+// testFingerprint is the SHA-256 fingerprint of testCert.
+var testFingerprint = func() string {
+	sum := sha256.Sum256(testCert.Raw)
+	return hex.EncodeToString(sum[:])
+}()
+
+func TestParsePEM(t *testing.T) {
+	assert := assert.New(t)
+
+	// Leading non-certificate blocks are skipped
+	cert, err := ParsePEM("-----BEGIN EC PARAMETERS-----\nBggqhkjOPQMBBw==\n-----END EC PARAMETERS-----\n" + testPEM)
+	assert.NoError(err)
+	assert.Equal("CN=billing,O=Acme", cert.Subject)
+	assert.Equal("CN=billing,O=Acme", cert.Issuer)
+	assert.Equal("abc123", cert.SerialNumber)
+	assert.Equal(testFingerprint, cert.Fingerprint)
+	assert.Equal([]string{"billing.partner.example.com"}, cert.DNSNames)
+	assert.Equal([]string{"ops@acme.example"}, cert.EmailAddresses)
+	assert.Equal([]string{"10.0.0.1"}, cert.IPAddresses)
+	assert.Equal([]string{"spiffe://acme.example/ns/billing"}, cert.URIs)
+	assert.Equal([]string{"billing.partner.example.com", "ops@acme.example", "10.0.0.1", "spiffe://acme.example/ns/billing"}, cert.SANs())
+	assert.True(now.Add(24 * time.Hour).Equal(cert.NotAfter))
+	assert.Equal(testCert.Raw, cert.X509.Raw)
+
+	_, err = ParsePEM("not a certificate")
+	assert.ErrorIs(err, errNoCertificate)
+
+	_, err = ParsePEM(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")})))
+	assert.Error(err)
+}
+
+func TestSources(t *testing.T) {
+	assert := assert.New(t)
+	type pemKey struct{}
+
+	request := events.APIGatewayProxyRequest{
+		Headers: map[string]string{"x-amzn-mtls-clientcert": url.PathEscape(testPEM)},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Authorizer: map[string]interface{}{"clientCertPem": testPEM, "other": 1},
+		},
+	}
+	ctx := context.WithValue(context.Background(), pemKey{}, testPEM)
+
+	assert.Equal(testPEM, FromAuthorizer("clientCertPem")(ctx, request))
+	assert.Empty(FromAuthorizer("other")(ctx, request))
+	assert.Empty(FromAuthorizer("missing")(ctx, request))
+	assert.Equal(testPEM, FromHeader("X-Amzn-Mtls-Clientcert")(ctx, request))
+	assert.Empty(FromHeader("X-Missing")(ctx, request))
+	assert.Equal(testPEM, FromContext(pemKey{})(ctx, request))
+	assert.Empty(FromContext(pemKey{})(context.Background(), request))
+}
@@ -0,0 +1,210 @@
+package mtls
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+)
+
+const (
+	// defaultAuthorizerKey is the default authorizer context entry holding the certificate.
+	defaultAuthorizerKey = "clientCertPem"
+
+	// defaultErrorBody is the default response body when the client is not allowed.
+	defaultErrorBody = "Forbidden"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the default key type used to store the client Certificate within the context.
+type CtxKey struct{}
+
+// Config is the configuration for the ClientCert middleware.
+type Config struct {
+	ctxKey           any
+	source           Source
+	subjects         []string
+	sanPatterns      []string
+	fingerprints     []string
+	now              func() time.Time
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the ClientCert configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the client Certificate to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithSource sets where the client certificate is read from. The default is FromAuthorizer("clientCertPem").
+func WithSource(source Source) Option {
+	return func(c *Config) {
+		c.source = source
+	}
+}
+
+// WithAllowedSubjects allows certificates whose subject distinguished name, in RFC 2253 form, is one of subjects.
+func WithAllowedSubjects(subjects ...string) Option {
+	return func(c *Config) {
+		c.subjects = append(c.subjects, subjects...)
+	}
+}
+
+// WithAllowedSANs allows certificates having a subject alternative name matching one of patterns.
+// In a pattern, "*" matches any sequence of characters (e.g. "*.partner.example.com" or "spiffe://acme/*").
+func WithAllowedSANs(patterns ...string) Option {
+	return func(c *Config) {
+		c.sanPatterns = append(c.sanPatterns, patterns...)
+	}
+}
+
+// WithAllowedFingerprints allows certificates whose SHA-256 fingerprint is one of fingerprints.
+// Fingerprints are hexadecimal, case-insensitive and may contain colons.
+func WithAllowedFingerprints(fingerprints ...string) Option {
+	return func(c *Config) {
+		for _, fp := range fingerprints {
+			c.fingerprints = append(c.fingerprints, normalizeFingerprint(fp))
+		}
+	}
+}
+
+// WithClock sets the function returning the current time, used to check the validity period.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when the client is not allowed.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// ClientCert creates middleware that inspects the mutual TLS client certificate of requests.
+//
+// The PEM encoded certificate is read from the configured Source and parsed. Requests without a valid
+// certificate, with a certificate outside its validity period, or with a certificate not matching any of
+// the allow-lists (if configured) are rejected with 403 Forbidden. Without allow-lists, any certificate is accepted.
+//
+// API Gateway verifies the certificate chain against the trust store before invoking the function,
+// so this middleware does not verify signatures. On success the Certificate is set in the context
+// under CtxKey{} (or the key given with WithCtxKey).
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, mtls.ClientCert(
+//	    mtls.WithAllowedSubjects("CN=billing,O=Acme"),
+//	    mtls.WithAllowedSANs("*.partner.example.com"),
+//	))
+//
+//	// In the handler
+//	cert := ctx.Value(mtls.CtxKey{}).(mtls.Certificate)
+func ClientCert(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		source:           FromAuthorizer(defaultAuthorizerKey),
+		now:              time.Now,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusForbidden,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			data := config.source(ctx, request)
+			if data == "" {
+				return errorResponse, nil
+			}
+			cert, err := ParsePEM(data)
+			if err != nil {
+				return errorResponse, nil
+			}
+
+			now := config.now()
+			if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+				return errorResponse, nil
+			}
+			if !config.allowed(cert) {
+				return errorResponse, nil
+			}
+
+			ctxWithCert := context.WithValue(ctx, config.ctxKey, cert)
+			return next(ctxWithCert, request)
+		}
+	}
+}
+
+// allowed reports whether cert matches one of the allow-lists, or whether no allow-list is configured.
+func (c *Config) allowed(cert Certificate) bool {
+	if len(c.subjects) == 0 && len(c.sanPatterns) == 0 && len(c.fingerprints) == 0 {
+		return true
+	}
+	if slices.Contains(c.subjects, cert.Subject) {
+		return true
+	}
+	if slices.Contains(c.fingerprints, cert.Fingerprint) {
+		return true
+	}
+	for _, san := range cert.SANs() {
+		for _, pattern := range c.sanPatterns {
+			if matchWildcard(pattern, san) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeFingerprint removes colons from a hexadecimal fingerprint and lowercases it.
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.ReplaceAll(fp, ":", ""))
+}
+
+// matchWildcard reports whether s matches pattern, where "*" matches any sequence of characters.
+// The comparison is case-insensitive.
+func matchWildcard(pattern, s string) bool {
+	pattern = strings.ToLower(pattern)
+	s = strings.ToLower(s)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
@@ -0,0 +1,124 @@
+package mtls
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRequest creates a request carrying pem in the authorizer context.
+func createRequest(pem string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Authorizer: map[string]interface{}{"clientCertPem": pem},
+		},
+	}
+}
+
+func TestClientCert(t *testing.T) {
+	colonFingerprint := strings.ToUpper(testFingerprint[:2]) + ":" + testFingerprint[2:]
+
+	tests := []struct {
+		name     string
+		pem      string
+		opts     []Option
+		clock    time.Time
+		expected bool
+	}{
+		{name: "Any certificate", pem: testPEM, expected: true},
+		{name: "No certificate", pem: "", expected: false},
+		{name: "Invalid certificate", pem: "garbage", expected: false},
+		{name: "Expired", pem: testPEM, clock: now.Add(48 * time.Hour), expected: false},
+		{name: "Not yet valid", pem: testPEM, clock: now.Add(-48 * time.Hour), expected: false},
+		{name: "Allowed subject", pem: testPEM, opts: []Option{WithAllowedSubjects("CN=other", "CN=billing,O=Acme")}, expected: true},
+		{name: "Unknown subject", pem: testPEM, opts: []Option{WithAllowedSubjects("CN=other")}, expected: false},
+		{name: "Allowed DNS SAN", pem: testPEM, opts: []Option{WithAllowedSANs("*.PARTNER.example.com")}, expected: true},
+		{name: "Allowed URI SAN", pem: testPEM, opts: []Option{WithAllowedSANs("spiffe://acme.example/*")}, expected: true},
+		{name: "Allowed email SAN", pem: testPEM, opts: []Option{WithAllowedSANs("ops@acme.example")}, expected: true},
+		{name: "Unknown SAN", pem: testPEM, opts: []Option{WithAllowedSANs("*.other.example.com")}, expected: false},
+		{name: "Allowed fingerprint", pem: testPEM, opts: []Option{WithAllowedFingerprints(colonFingerprint)}, expected: true},
+		{name: "Unknown fingerprint", pem: testPEM, opts: []Option{WithAllowedFingerprints("00ff")}, expected: false},
+		{name: "Any allow-list matches", pem: testPEM, opts: []Option{WithAllowedSubjects("CN=other"), WithAllowedSANs("*.partner.example.com")}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			clock := now
+			if !tt.clock.IsZero() {
+				clock = tt.clock
+			}
+			var cert Certificate
+			opts := append([]Option{WithClock(func() time.Time { return clock })}, tt.opts...)
+
+			handler := ClientCert(opts...)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				cert = ctx.Value(CtxKey{}).(Certificate)
+				return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+			})
+
+			response, err := handler(context.Background(), createRequest(tt.pem))
+			assert.NoError(err)
+			if tt.expected {
+				assert.Equal(http.StatusOK, response.StatusCode)
+				assert.Equal(testFingerprint, cert.Fingerprint)
+			} else {
+				assert.Equal(http.StatusForbidden, response.StatusCode)
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestClientCert_Options(t *testing.T) {
+	assert := assert.New(t)
+	type certKey struct{}
+
+	handler := ClientCert(
+		WithCtxKey(certKey{}),
+		WithSource(FromHeader("X-Client-Cert")),
+		WithClock(func() time.Time { return now }),
+		WithResponse("application/json", `{"error":"unknown client"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Equal("abc123", ctx.Value(certKey{}).(Certificate).SerialNumber)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"X-Client-Cert": testPEM}})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+
+	// The authorizer context is not read
+	response, err = handler(context.Background(), createRequest(testPEM))
+	assert.NoError(err)
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+	assert.Equal(`{"error":"unknown client"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{"a.example.com", "A.example.com", true},
+		{"a.example.com", "b.example.com", false},
+		{"*.example.com", "a.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"spiffe://acme/*", "spiffe://acme/ns/x", true},
+		{"spiffe://*/ns/*", "spiffe://acme/ns/x", true},
+		{"spiffe://*/ns/*", "spiffe://acme/other/x", false},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"*", "", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, matchWildcard(tt.pattern, tt.s), "%s %s", tt.pattern, tt.s)
+	}
+}
@@ -0,0 +1,77 @@
+package negotiate
+
+import (
+	"strings"
+
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/accept"
+)
+
+// matchMediaType returns the specificity of the match between the media range r and the offered
+// media type and parameters, or -1 if they do not match.
+func matchMediaType(r accept.Range, offer string, offerParams map[string]string) int {
+	if !accept.MatchMediaType(r.Value, offer) {
+		return -1
+	}
+	rangeType, rangeSubtype, _ := strings.Cut(r.Value, "/")
+	specificity := 2
+	switch {
+	case rangeType == "*":
+		specificity = 0
+	case strings.HasPrefix(rangeSubtype, "*"):
+		specificity = 1
+	}
+	for k, v := range r.Params {
+		if !strings.EqualFold(offerParams[k], v) {
+			return -1
+		}
+	}
+	// Ranges with more parameters are more specific
+	return specificity*100 + len(r.Params)
+}
+
+// matchLanguage returns the specificity of the match between the language range r and the offered
+// language tag, or -1 if they do not match. Ranges match tags equal to them or starting with them
+// followed by "-" (RFC 4647 basic filtering).
+func matchLanguage(r accept.Range, offer string) int {
+	switch {
+	case r.Value == "*":
+		return 0
+	case r.Value == offer || strings.HasPrefix(offer, r.Value+"-"):
+		return len(r.Value)
+	}
+	return -1
+}
+
+// matchCharset returns the specificity of the match between r and the offered charset, or -1 if they do not match.
+func matchCharset(r accept.Range, offer string) int {
+	switch r.Value {
+	case "*":
+		return 0
+	case offer:
+		return 1
+	}
+	return -1
+}
+
+// best returns the index of the offer with the highest quality, or -1 if no offer is acceptable.
+// The quality of an offer is the q-value of the most specific range matching it. Ties are broken by the order
+// of the offers, which expresses the server's preference. Without any range, as when the header is missing,
+// every offer is acceptable and the first one is selected.
+func best(ranges []accept.Range, n int, match func(r accept.Range, i int) int) int {
+	if len(ranges) == 0 {
+		return 0
+	}
+	bestIndex, bestQ := -1, 0.0
+	for i := range n {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := match(r, i); s > specificity {
+				q, specificity = r.Q, s
+			}
+		}
+		if q > bestQ {
+			bestIndex, bestQ = i, q
+		}
+	}
+	return bestIndex
+}
@@ -0,0 +1,66 @@
+package negotiate
+
+import (
+	"testing"
+
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/accept"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchMediaType(t *testing.T) {
+	tests := []struct {
+		rangeValue  string
+		rangeParams map[string]string
+		offer       string
+		offerParams map[string]string
+		want        int
+	}{
+		{rangeValue: "*/*", offer: "application/json", want: 0},
+		{rangeValue: "application/*", offer: "application/json", want: 100},
+		{rangeValue: "application/json", offer: "application/json", want: 200},
+		{rangeValue: "text/*", offer: "application/json", want: -1},
+		{rangeValue: "application/xml", offer: "application/json", want: -1},
+		{rangeValue: "text/csv", rangeParams: map[string]string{"header": "present"}, offer: "text/csv", offerParams: map[string]string{"header": "present"}, want: 201},
+		{rangeValue: "text/csv", rangeParams: map[string]string{"header": "present"}, offer: "text/csv", want: -1},
+		{rangeValue: "text/csv", offer: "text/csv", offerParams: map[string]string{"header": "present"}, want: 200},
+	}
+	for _, tt := range tests {
+		r := accept.Range{Value: tt.rangeValue, Params: tt.rangeParams, Q: 1}
+		assert.Equal(t, tt.want, matchMediaType(r, tt.offer, tt.offerParams), "%s %v / %s %v", tt.rangeValue, tt.rangeParams, tt.offer, tt.offerParams)
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	assert.Equal(t, 0, matchLanguage(accept.Range{Value: "*"}, "en-us"))
+	assert.Equal(t, 2, matchLanguage(accept.Range{Value: "en"}, "en-us"))
+	assert.Equal(t, 5, matchLanguage(accept.Range{Value: "en-us"}, "en-us"))
+	assert.Equal(t, -1, matchLanguage(accept.Range{Value: "en-us"}, "en"))
+	assert.Equal(t, -1, matchLanguage(accept.Range{Value: "e"}, "en"))
+}
+
+func TestBest(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/csv"}
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "no header", header: "", want: 0},
+		{name: "exact", header: "text/csv", want: 2},
+		{name: "highest q wins", header: "application/json;q=0.5, application/xml;q=0.8", want: 1},
+		{name: "tie broken by server order", header: "text/csv, application/xml", want: 1},
+		{name: "most specific range decides", header: "application/*, application/json;q=0", want: 1},
+		{name: "wildcard", header: "text/html, */*;q=0.1", want: 0},
+		{name: "nothing acceptable", header: "text/html", want: -1},
+		{name: "q=0 is not acceptable", header: "application/json;q=0, application/xml;q=0, text/csv;q=0", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges := accept.ParseMediaRanges(tt.header)
+			got := best(ranges, len(offers), func(r accept.Range, i int) int {
+				return matchMediaType(r, offers[i], nil)
+			})
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package negotiate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/accept"
+)
+
+const (
+	// defaultErrorBody is the default response body when no offer is acceptable.
+	defaultErrorBody = "Not Acceptable"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// CtxKey is the default key type used to store the negotiated Result within the context.
+type CtxKey struct{}
+
+// Result is the outcome of content negotiation. Each field holds the selected offer as configured,
+// or "" if the corresponding dimension is not negotiated.
+type Result struct {
+	// Type is the selected media type (e.g. "application/json").
+	Type string
+	// Language is the selected language tag (e.g. "en-US").
+	Language string
+	// Charset is the selected charset (e.g. "utf-8").
+	Charset string
+}
+
+// mediaOffer is a parsed media type offered by the server.
+type mediaOffer struct {
+	mediaType string
+	params    map[string]string
+}
+
+// Config is the configuration for the Negotiate middleware.
+type Config struct {
+	ctxKey           any
+	types            []string
+	languages        []string
+	charsets         []string
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the Negotiate configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the negotiated Result to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithTypes sets the media types offered by the server, in order of preference, and negotiates them
+// against the Accept header. Offers may have parameters (e.g. "text/csv; header=present").
+func WithTypes(types ...string) Option {
+	return func(c *Config) {
+		c.types = types
+	}
+}
+
+// WithLanguages sets the language tags offered by the server, in order of preference, and negotiates them
+// against the Accept-Language header.
+func WithLanguages(languages ...string) Option {
+	return func(c *Config) {
+		c.languages = languages
+	}
+}
+
+// WithCharsets sets the charsets offered by the server, in order of preference, and negotiates them
+// against the Accept-Charset header.
+func WithCharsets(charsets ...string) Option {
+	return func(c *Config) {
+		c.charsets = charsets
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when no offer is acceptable.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// Negotiate creates middleware that selects the representation of the response from the offers of the server
+// and the Accept, Accept-Language and Accept-Charset request headers.
+//
+// Each dimension configured with WithTypes, WithLanguages or WithCharsets is negotiated independently.
+// An offer's quality is the q-value of the most specific range matching it: media ranges may use
+// "*/*" and "type/*" wildcards, language ranges match tags they are a prefix of (e.g. "en" matches "en-US"),
+// and "*" matches any language or charset. The offer with the highest quality is selected, ties being broken
+// by the order of the offers. A missing or empty header accepts anything, so the first offer is selected.
+//
+// The Result is set in the context under CtxKey{} (or the key given with WithCtxKey), and the negotiated
+// header names are added to the Vary response header. If no offer is acceptable for a dimension,
+// the middleware returns 406 Not Acceptable.
+//
+// Negotiate panics if no offer is configured or a media type offer is invalid.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, negotiate.Negotiate(negotiate.WithTypes("application/json", "application/xml", "text/csv")))
+//
+//	// In the handler
+//	switch ctx.Value(negotiate.CtxKey{}).(negotiate.Result).Type {
+//	case "application/xml":
+//	    // ...
+//	}
+func Negotiate(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey:           CtxKey{},
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if len(config.types) == 0 && len(config.languages) == 0 && len(config.charsets) == 0 {
+		panic(errors.New("negotiate: no offer configured"))
+	}
+
+	typeOffers := make([]mediaOffer, len(config.types))
+	for i, t := range config.types {
+		mediaType, params, err := mime.ParseMediaType(t)
+		if err == nil && !strings.Contains(mediaType, "/") {
+			err = errors.New("missing subtype")
+		}
+		if err != nil {
+			panic(fmt.Errorf("negotiate: invalid media type %q: %w", t, err))
+		}
+		typeOffers[i] = mediaOffer{mediaType: mediaType, params: params}
+	}
+	languageOffers := lowerAll(config.languages)
+	charsetOffers := lowerAll(config.charsets)
+
+	var vary []string
+	if len(config.types) > 0 {
+		vary = append(vary, "Accept")
+	}
+	if len(config.languages) > 0 {
+		vary = append(vary, "Accept-Language")
+	}
+	if len(config.charsets) > 0 {
+		vary = append(vary, "Accept-Charset")
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			h := header.Request(&request)
+			var result Result
+			ok := true
+
+			if len(typeOffers) > 0 {
+				ranges := accept.ParseMediaRanges(strings.Join(h.Values("Accept"), ","))
+				i := best(ranges, len(typeOffers), func(r accept.Range, i int) int {
+					return matchMediaType(r, typeOffers[i].mediaType, typeOffers[i].params)
+				})
+				if i >= 0 {
+					result.Type = config.types[i]
+				} else {
+					ok = false
+				}
+			}
+			if len(languageOffers) > 0 {
+				i := negotiateTokens(h.Values("Accept-Language"), languageOffers, matchLanguage)
+				if i >= 0 {
+					result.Language = config.languages[i]
+				} else {
+					ok = false
+				}
+			}
+			if len(charsetOffers) > 0 {
+				i := negotiateTokens(h.Values("Accept-Charset"), charsetOffers, matchCharset)
+				if i >= 0 {
+					result.Charset = config.charsets[i]
+				} else {
+					ok = false
+				}
+			}
+
+			var response events.APIGatewayProxyResponse
+			if !ok {
+				response = events.APIGatewayProxyResponse{
+					StatusCode: http.StatusNotAcceptable,
+					Body:       config.errorBody,
+					Headers:    map[string]string{"Content-Type": config.errorContentType},
+				}
+			} else {
+				var err error
+				response, err = next(context.WithValue(ctx, config.ctxKey, result), request)
+				if err != nil {
+					return response, err
+				}
+			}
+
+			for _, field := range vary {
+				header.AddVary(&response, field)
+			}
+			return response, nil
+		}
+	}
+}
+
+// negotiateTokens selects the best offer for an Accept-Language or Accept-Charset header.
+func negotiateTokens(values []string, offers []string, match func(r accept.Range, offer string) int) int {
+	ranges := accept.ParseTokens(strings.Join(values, ","))
+	return best(ranges, len(offers), func(r accept.Range, i int) int {
+		return match(r, offers[i])
+	})
+}
+
+// lowerAll returns a copy of values in lowercase.
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
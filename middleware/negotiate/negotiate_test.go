@@ -0,0 +1,159 @@
+package negotiate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// resultHandler returns a handler that records the negotiated Result and returns 200 OK.
+func resultHandler(result *Result, called *bool) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		*called = true
+		*result, _ = ctx.Value(CtxKey{}).(Result)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Headers: map[string]string{"vary": "Origin"}}, nil
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	opts := []Option{
+		WithTypes("application/json", "application/xml", "text/csv"),
+		WithLanguages("en-US", "ja"),
+		WithCharsets("utf-8", "iso-8859-1"),
+	}
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+		want       Result
+	}{
+		{
+			name:       "no headers",
+			wantStatus: http.StatusOK,
+			want:       Result{Type: "application/json", Language: "en-US", Charset: "utf-8"},
+		},
+		{
+			name: "preferences",
+			headers: map[string]string{
+				"accept":          "text/csv, application/json;q=0.5",
+				"accept-language": "ja-JP, ja;q=0.9, en;q=0.8",
+				"accept-charset":  "iso-8859-1, *;q=0.1",
+			},
+			wantStatus: http.StatusOK,
+			want:       Result{Type: "text/csv", Language: "ja", Charset: "iso-8859-1"},
+		},
+		{
+			name:       "type wildcard",
+			headers:    map[string]string{"Accept": "text/*"},
+			wantStatus: http.StatusOK,
+			want:       Result{Type: "text/csv", Language: "en-US", Charset: "utf-8"},
+		},
+		{
+			name:       "language prefix",
+			headers:    map[string]string{"Accept-Language": "fr, en"},
+			wantStatus: http.StatusOK,
+			want:       Result{Type: "application/json", Language: "en-US", Charset: "utf-8"},
+		},
+		{
+			name:       "unacceptable type",
+			headers:    map[string]string{"Accept": "text/html"},
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "unacceptable language",
+			headers:    map[string]string{"Accept-Language": "fr"},
+			wantStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:       "unacceptable charset",
+			headers:    map[string]string{"Accept-Charset": "utf-16"},
+			wantStatus: http.StatusNotAcceptable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			var result Result
+			called := false
+			handler := Negotiate(opts...)(resultHandler(&result, &called))
+
+			response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: tt.headers})
+			assert.NoError(err)
+			assert.Equal(tt.wantStatus, response.StatusCode)
+			assert.Equal(tt.wantStatus == http.StatusOK, called)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(tt.want, result)
+				assert.Equal(map[string]string{"Vary": "Origin, Accept, Accept-Language, Accept-Charset"}, response.Headers)
+			} else {
+				assert.Equal(defaultErrorBody, response.Body)
+				assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+				assert.Equal("Accept, Accept-Language, Accept-Charset", response.Headers["Vary"])
+			}
+		})
+	}
+}
+
+func TestNegotiate_MultiValueAccept(t *testing.T) {
+	var result Result
+	called := false
+	handler := Negotiate(WithTypes("application/json", "application/xml"))(resultHandler(&result, &called))
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"Accept": {"application/json;q=0.1", "application/xml"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Type: "application/xml"}, result)
+}
+
+func TestNegotiate_OfferParameters(t *testing.T) {
+	var result Result
+	called := false
+	handler := Negotiate(WithTypes("text/csv; header=absent", "text/csv; header=present"))(resultHandler(&result, &called))
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Accept": "text/csv;header=present"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv; header=present", result.Type)
+}
+
+func TestNegotiate_Options(t *testing.T) {
+	assert := assert.New(t)
+	type myKey struct{}
+	var result Result
+	handler := Negotiate(
+		WithTypes("application/json"),
+		WithCtxKey(myKey{}),
+		WithResponse("application/json", `{"error":"not acceptable"}`),
+	)(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		result = ctx.Value(myKey{}).(Result)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(Result{Type: "application/json"}, result)
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"Accept": "text/html"}})
+	assert.NoError(err)
+	assert.Equal(http.StatusNotAcceptable, response.StatusCode)
+	assert.Equal(`{"error":"not acceptable"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestNegotiate_HandlerError(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	handler := Negotiate(WithTypes("application/json"))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, handlerErr
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Empty(t, response.Headers)
+}
+
+func TestNegotiate_Panics(t *testing.T) {
+	assert.Panics(t, func() { Negotiate() })
+	assert.Panics(t, func() { Negotiate(WithTypes("json")) })
+}
@@ -0,0 +1,166 @@
+package normalize
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+)
+
+// Shape is the layout of the response headers produced by the Normalize middleware.
+type Shape int
+
+const (
+	// ShapeSplit stores single-valued headers in Headers and headers with several values in MultiValueHeaders.
+	// Each header name appears in only one of the two maps. This suits API Gateway REST APIs.
+	ShapeSplit Shape = iota
+
+	// ShapeMultiValue stores all headers in MultiValueHeaders and leaves Headers nil.
+	// This suits integrations that only read MultiValueHeaders, such as ALB with multi-value headers enabled.
+	ShapeMultiValue
+)
+
+// Config is the configuration for the Normalize middleware.
+type Config struct {
+	keyFunc func(string) string
+	shape   Shape
+}
+
+// Option is a function type to modify the Normalize configuration.
+type Option func(*Config)
+
+// WithKeyFunc sets the function normalizing header names, on both the request and the response.
+// The default is http.CanonicalHeaderKey (e.g. "content-type" becomes "Content-Type");
+// use strings.ToLower to get lowercase names instead.
+func WithKeyFunc(fn func(string) string) Option {
+	return func(c *Config) {
+		c.keyFunc = fn
+	}
+}
+
+// WithShape sets the layout of the response headers. The default is ShapeSplit.
+func WithShape(shape Shape) Option {
+	return func(c *Config) {
+		c.shape = shape
+	}
+}
+
+// Normalize creates middleware that reconciles the single and multi-value forms of headers and query parameters.
+//
+// On the request, header names are normalized with the key function and variants of the same name are merged.
+// MultiValueHeaders and MultiValueQueryStringParameters then hold every value, and Headers and
+// QueryStringParameters hold the last value of each name, as API Gateway does for duplicated values.
+// Names present in only one of the two forms are added to the other. Query parameter names are case-sensitive
+// and are not renamed.
+//
+// On the response, Headers and MultiValueHeaders are merged, following API Gateway: values from MultiValueHeaders
+// come first, and a value from Headers is only added if it is not already present.
+// The merged headers are then laid out according to WithShape. Responses returned together with an error
+// are not modified.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, normalize.Normalize())
+//
+//	// In the handler, both forms are consistent
+//	contentType := request.Headers["Content-Type"]
+//	accept := request.MultiValueHeaders["Accept"]
+func Normalize(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		keyFunc: http.CanonicalHeaderKey,
+		shape:   ShapeSplit,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			request.Headers, request.MultiValueHeaders = normalizeRequest(request.Headers, request.MultiValueHeaders, config.keyFunc)
+			request.QueryStringParameters, request.MultiValueQueryStringParameters = normalizeRequest(
+				request.QueryStringParameters, request.MultiValueQueryStringParameters, func(k string) string { return k })
+
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			normalizeResponse(&response, &config)
+			return response, nil
+		}
+	}
+}
+
+// normalizeRequest returns new single and multi-value maps holding the same names.
+// The original maps are not modified.
+func normalizeRequest(single map[string]string, multi map[string][]string, keyFunc func(string) string) (map[string]string, map[string][]string) {
+	if len(single) == 0 && len(multi) == 0 {
+		return single, multi
+	}
+
+	merged := make(map[string][]string, len(multi))
+	for _, k := range slices.Sorted(maps.Keys(multi)) {
+		key := keyFunc(k)
+		merged[key] = append(merged[key], multi[k]...)
+	}
+	// Single values are only used for names missing from the multi-value form,
+	// which holds every value of a name
+	inMulti := maps.Clone(merged)
+	for _, k := range slices.Sorted(maps.Keys(single)) {
+		key := keyFunc(k)
+		if _, ok := inMulti[key]; !ok {
+			merged[key] = append(merged[key], single[k])
+		}
+	}
+
+	newSingle := make(map[string]string, len(merged))
+	for k, v := range merged {
+		if len(v) > 0 {
+			newSingle[k] = v[len(v)-1]
+		}
+	}
+	return newSingle, merged
+}
+
+// normalizeResponse merges the response headers and lays them out according to the configured shape.
+func normalizeResponse(response *events.APIGatewayProxyResponse, config *Config) {
+	if len(response.Headers) == 0 && len(response.MultiValueHeaders) == 0 {
+		return
+	}
+
+	merged := make(map[string][]string, len(response.Headers)+len(response.MultiValueHeaders))
+	for _, k := range slices.Sorted(maps.Keys(response.MultiValueHeaders)) {
+		key := config.keyFunc(k)
+		merged[key] = append(merged[key], response.MultiValueHeaders[k]...)
+	}
+	for _, k := range slices.Sorted(maps.Keys(response.Headers)) {
+		key := config.keyFunc(k)
+		if v := response.Headers[k]; !slices.Contains(merged[key], v) {
+			merged[key] = append(merged[key], v)
+		}
+	}
+
+	response.Headers = nil
+	response.MultiValueHeaders = nil
+	for k, v := range merged {
+		if len(v) == 0 {
+			continue
+		}
+		if config.shape == ShapeSplit && len(v) == 1 {
+			if response.Headers == nil {
+				response.Headers = make(map[string]string)
+			}
+			response.Headers[k] = v[0]
+			continue
+		}
+		if response.MultiValueHeaders == nil {
+			response.MultiValueHeaders = make(map[string][]string)
+		}
+		response.MultiValueHeaders[k] = v
+	}
+}
@@ -0,0 +1,161 @@
+package normalize
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureHandler returns a handler that records the request and returns response.
+func captureHandler(captured *events.APIGatewayProxyRequest, response events.APIGatewayProxyResponse) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		*captured = request
+		return response, nil
+	}
+}
+
+func TestNormalize_RequestHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    events.APIGatewayProxyRequest
+		wantSingle map[string]string
+		wantMulti  map[string][]string
+	}{
+		{
+			name:       "no headers",
+			request:    events.APIGatewayProxyRequest{},
+			wantSingle: nil,
+			wantMulti:  nil,
+		},
+		{
+			name:       "only single values",
+			request:    events.APIGatewayProxyRequest{Headers: map[string]string{"content-type": "application/json"}},
+			wantSingle: map[string]string{"Content-Type": "application/json"},
+			wantMulti:  map[string][]string{"Content-Type": {"application/json"}},
+		},
+		{
+			name:       "only multi values",
+			request:    events.APIGatewayProxyRequest{MultiValueHeaders: map[string][]string{"accept": {"text/html", "application/json"}}},
+			wantSingle: map[string]string{"Accept": "application/json"},
+			wantMulti:  map[string][]string{"Accept": {"text/html", "application/json"}},
+		},
+		{
+			name: "both forms",
+			request: events.APIGatewayProxyRequest{
+				Headers:           map[string]string{"accept": "application/json", "host": "example.com"},
+				MultiValueHeaders: map[string][]string{"Accept": {"text/html", "application/json"}},
+			},
+			wantSingle: map[string]string{"Accept": "application/json", "Host": "example.com"},
+			wantMulti:  map[string][]string{"Accept": {"text/html", "application/json"}, "Host": {"example.com"}},
+		},
+		{
+			name: "variants of a name are merged",
+			request: events.APIGatewayProxyRequest{
+				MultiValueHeaders: map[string][]string{"X-Tag": {"a"}, "x-tag": {"b"}},
+			},
+			wantSingle: map[string]string{"X-Tag": "b"},
+			wantMulti:  map[string][]string{"X-Tag": {"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured events.APIGatewayProxyRequest
+			handler := Normalize()(captureHandler(&captured, events.APIGatewayProxyResponse{StatusCode: http.StatusOK}))
+			_, err := handler(context.Background(), tt.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSingle, captured.Headers)
+			assert.Equal(t, tt.wantMulti, captured.MultiValueHeaders)
+		})
+	}
+}
+
+func TestNormalize_RequestQuery(t *testing.T) {
+	assert := assert.New(t)
+	var captured events.APIGatewayProxyRequest
+	handler := Normalize()(captureHandler(&captured, events.APIGatewayProxyResponse{StatusCode: http.StatusOK}))
+
+	request := events.APIGatewayProxyRequest{
+		QueryStringParameters:           map[string]string{"page": "2", "tag": "b"},
+		MultiValueQueryStringParameters: map[string][]string{"tag": {"a", "b"}, "Tag": {"c"}},
+	}
+	_, err := handler(context.Background(), request)
+	assert.NoError(err)
+	// Query parameter names are case-sensitive
+	assert.Equal(map[string]string{"page": "2", "tag": "b", "Tag": "c"}, captured.QueryStringParameters)
+	assert.Equal(map[string][]string{"page": {"2"}, "tag": {"a", "b"}, "Tag": {"c"}}, captured.MultiValueQueryStringParameters)
+
+	// The caller's request is not modified
+	assert.Equal(map[string]string{"page": "2", "tag": "b"}, request.QueryStringParameters)
+}
+
+func TestNormalize_Response(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		response   events.APIGatewayProxyResponse
+		wantSingle map[string]string
+		wantMulti  map[string][]string
+	}{
+		{
+			name:     "no headers",
+			response: events.APIGatewayProxyResponse{},
+		},
+		{
+			name: "split",
+			response: events.APIGatewayProxyResponse{
+				Headers:           map[string]string{"content-type": "text/plain", "set-cookie": "c=3", "Vary": "Accept"},
+				MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1", "b=2"}, "vary": {"Accept"}, "x-single": {"x"}},
+			},
+			wantSingle: map[string]string{"Content-Type": "text/plain", "Vary": "Accept", "X-Single": "x"},
+			wantMulti:  map[string][]string{"Set-Cookie": {"a=1", "b=2", "c=3"}},
+		},
+		{
+			name: "multi value",
+			opts: []Option{WithShape(ShapeMultiValue)},
+			response: events.APIGatewayProxyResponse{
+				Headers:           map[string]string{"content-type": "text/plain"},
+				MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1"}},
+			},
+			wantMulti: map[string][]string{"Content-Type": {"text/plain"}, "Set-Cookie": {"a=1"}},
+		},
+		{
+			name:       "lowercase keys",
+			opts:       []Option{WithKeyFunc(strings.ToLower)},
+			response:   events.APIGatewayProxyResponse{Headers: map[string]string{"Content-Type": "text/plain"}},
+			wantSingle: map[string]string{"content-type": "text/plain"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured events.APIGatewayProxyRequest
+			handler := Normalize(tt.opts...)(captureHandler(&captured, tt.response))
+			response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSingle, response.Headers)
+			assert.Equal(t, tt.wantMulti, response.MultiValueHeaders)
+		})
+	}
+}
+
+func TestNormalize_KeyFuncAppliesToRequest(t *testing.T) {
+	var captured events.APIGatewayProxyRequest
+	handler := Normalize(WithKeyFunc(strings.ToLower))(captureHandler(&captured, events.APIGatewayProxyResponse{}))
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{"Content-Type": "application/json"}})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"content-type": "application/json"}, captured.Headers)
+}
+
+func TestNormalize_HandlerError(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	handler := Normalize()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{Headers: map[string]string{"content-type": "text/plain"}}, handlerErr
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Equal(t, map[string]string{"content-type": "text/plain"}, response.Headers)
+}
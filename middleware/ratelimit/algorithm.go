@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Result is the outcome of a rate limiting decision.
+type Result struct {
+	// Allowed reports whether the request may proceed.
+	Allowed bool
+	// Limit is the maximum number of requests allowed in a burst or window.
+	Limit int
+	// Remaining is the number of requests still allowed right now.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until the next request will be allowed, if Allowed is false.
+	RetryAfter time.Duration
+}
+
+// Algorithm decides whether a request identified by key is allowed, using the state kept in store.
+type Algorithm interface {
+	Take(ctx context.Context, store Store, key string, now time.Time) (Result, error)
+}
+
+// tokenBucket implements the token bucket algorithm.
+type tokenBucket struct {
+	ratePerSecond float64
+	burst         int
+}
+
+// TokenBucket returns an Algorithm that refills rate tokens per interval into a bucket holding at most burst tokens.
+// Each request consumes one token, so bursts of up to burst requests are allowed, after which requests
+// are allowed at the refill rate.
+//
+// It panics if rate, interval or burst is not positive.
+//
+// Example: TokenBucket(10, time.Second, 20) allows 10 requests per second with bursts of 20.
+func TokenBucket(rate int, interval time.Duration, burst int) Algorithm {
+	if rate <= 0 || interval <= 0 || burst <= 0 {
+		panic(errors.New("ratelimit: token bucket rate, interval and burst must be positive"))
+	}
+	return &tokenBucket{
+		ratePerSecond: float64(rate) / interval.Seconds(),
+		burst:         burst,
+	}
+}
+
+// Take implements Algorithm.
+func (b *tokenBucket) Take(ctx context.Context, store Store, key string, now time.Time) (Result, error) {
+	burst := float64(b.burst)
+	// Keep the state until the bucket would be full again
+	ttl := time.Duration(burst / b.ratePerSecond * float64(time.Second))
+
+	var result Result
+	err := store.Update(ctx, key, now, ttl, func(state State, found bool) State {
+		tokens := burst
+		if found {
+			elapsed := now.Sub(state.Time).Seconds()
+			tokens = math.Min(burst, state.Value+math.Max(0, elapsed)*b.ratePerSecond)
+		}
+
+		result = Result{Limit: b.burst}
+		if tokens >= 1 {
+			tokens--
+			result.Allowed = true
+		} else {
+			result.RetryAfter = seconds((1 - tokens) / b.ratePerSecond)
+		}
+		result.Remaining = int(math.Floor(tokens))
+		result.Reset = seconds((burst - tokens) / b.ratePerSecond)
+
+		return State{Value: tokens, Time: now}
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// slidingWindow implements the sliding window counter algorithm.
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+}
+
+// SlidingWindow returns an Algorithm that allows limit requests per window.
+// The request count is estimated from the counts of the current and the previous fixed window,
+// weighted by how much of the previous window still overlaps the sliding window.
+//
+// It panics if limit or window is not positive.
+//
+// Example: SlidingWindow(100, time.Minute) allows 100 requests per minute.
+func SlidingWindow(limit int, window time.Duration) Algorithm {
+	if limit <= 0 || window <= 0 {
+		panic(errors.New("ratelimit: sliding window limit and window must be positive"))
+	}
+	return &slidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Take implements Algorithm.
+func (w *slidingWindow) Take(ctx context.Context, store Store, key string, now time.Time) (Result, error) {
+	start := now.Truncate(w.window)
+	limit := float64(w.limit)
+
+	var result Result
+	err := store.Update(ctx, key, now, 2*w.window, func(state State, found bool) State {
+		var current, previous float64
+		switch {
+		case !found:
+		case state.Time.Equal(start):
+			current, previous = state.Value, state.Previous
+		case state.Time.Equal(start.Add(-w.window)):
+			previous = state.Value
+		}
+
+		elapsed := now.Sub(start)
+		weight := 1 - elapsed.Seconds()/w.window.Seconds()
+		estimated := previous*weight + current
+
+		result = Result{Limit: w.limit, Reset: start.Add(w.window).Sub(now)}
+		if estimated+1 <= limit {
+			current++
+			estimated++
+			result.Allowed = true
+		} else {
+			result.RetryAfter = w.retryAfter(current, previous, elapsed)
+		}
+		result.Remaining = int(math.Max(0, math.Floor(limit-estimated)))
+
+		return State{Value: current, Previous: previous, Time: start}
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// retryAfter estimates the time until a request will be allowed again,
+// given the counts of the current and previous windows and the time elapsed in the current window.
+func (w *slidingWindow) retryAfter(current, previous float64, elapsed time.Duration) time.Duration {
+	// Budget left for the decaying previous window once the current count is accounted for
+	budget := float64(w.limit) - 1 - current
+	if budget < 0 || previous == 0 {
+		// Nothing can be done until the current window rolls over
+		return w.window - elapsed
+	}
+	// Solve previous * (1 - t/window) <= budget for t
+	t := time.Duration((1 - budget/previous) * float64(w.window))
+	if t <= elapsed {
+		return 0
+	}
+	return t - elapsed
+}
+
+// seconds converts a number of seconds to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
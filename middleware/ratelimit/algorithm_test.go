@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// start is the time at which the algorithm tests begin, aligned to a minute.
+var start = time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+
+func TestTokenBucket(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryStore()
+	bucket := TokenBucket(1, time.Second, 3)
+
+	// The full burst is available at first
+	for i := 0; i < 3; i++ {
+		result, err := bucket.Take(context.Background(), store, "client", start)
+		assert.NoError(err)
+		assert.True(result.Allowed)
+		assert.Equal(3, result.Limit)
+		assert.Equal(2-i, result.Remaining)
+	}
+
+	// The bucket is empty
+	result, err := bucket.Take(context.Background(), store, "client", start)
+	assert.NoError(err)
+	assert.False(result.Allowed)
+	assert.Equal(0, result.Remaining)
+	assert.Equal(time.Second, result.RetryAfter)
+	assert.Equal(3*time.Second, result.Reset)
+
+	// One token is refilled after a second
+	result, err = bucket.Take(context.Background(), store, "client", start.Add(time.Second))
+	assert.NoError(err)
+	assert.True(result.Allowed)
+	result, err = bucket.Take(context.Background(), store, "client", start.Add(time.Second))
+	assert.NoError(err)
+	assert.False(result.Allowed)
+
+	// The bucket never holds more than the burst
+	result, err = bucket.Take(context.Background(), store, "client", start.Add(time.Hour))
+	assert.NoError(err)
+	assert.True(result.Allowed)
+	assert.Equal(2, result.Remaining)
+
+	// Keys are independent
+	result, err = bucket.Take(context.Background(), store, "other", start)
+	assert.NoError(err)
+	assert.True(result.Allowed)
+	assert.Equal(2, result.Remaining)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryStore()
+	window := SlidingWindow(4, time.Minute)
+
+	// Four requests in the first window are allowed
+	for i := 0; i < 4; i++ {
+		result, err := window.Take(context.Background(), store, "client", start.Add(30*time.Second))
+		assert.NoError(err)
+		assert.True(result.Allowed)
+		assert.Equal(3-i, result.Remaining)
+		assert.Equal(30*time.Second, result.Reset)
+	}
+
+	// The fifth is rejected until the window rolls over
+	result, err := window.Take(context.Background(), store, "client", start.Add(45*time.Second))
+	assert.NoError(err)
+	assert.False(result.Allowed)
+	assert.Equal(15*time.Second, result.RetryAfter)
+
+	// A quarter into the next window, the previous window still counts for 3 requests
+	result, err = window.Take(context.Background(), store, "client", start.Add(75*time.Second))
+	assert.NoError(err)
+	assert.True(result.Allowed)
+	assert.Equal(0, result.Remaining)
+	result, err = window.Take(context.Background(), store, "client", start.Add(75*time.Second))
+	assert.NoError(err)
+	assert.False(result.Allowed)
+	assert.Equal(15*time.Second, result.RetryAfter)
+
+	// Two windows later, the history is forgotten
+	result, err = window.Take(context.Background(), store, "client", start.Add(3*time.Minute))
+	assert.NoError(err)
+	assert.True(result.Allowed)
+	assert.Equal(3, result.Remaining)
+}
+
+func TestAlgorithm_Panics(t *testing.T) {
+	assert.Panics(t, func() { TokenBucket(0, time.Second, 1) })
+	assert.Panics(t, func() { TokenBucket(1, 0, 1) })
+	assert.Panics(t, func() { TokenBucket(-1, time.Second, 1) })
+	assert.Panics(t, func() { TokenBucket(1, time.Second, 0) })
+	assert.Panics(t, func() { SlidingWindow(1, 0) })
+	assert.Panics(t, func() { SlidingWindow(1, -time.Minute) })
+	assert.Panics(t, func() { SlidingWindow(0, time.Minute) })
+	assert.Panics(t, func() { SlidingWindow(-1, time.Minute) })
+	assert.NotPanics(t, func() { TokenBucket(1, time.Second, 1) })
+	assert.NotPanics(t, func() { SlidingWindow(1, time.Minute) })
+}
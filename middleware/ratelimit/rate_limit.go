@@ -0,0 +1,197 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultErrorBody is the default response body when the rate limit is exceeded.
+	defaultErrorBody = "Too Many Requests"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+// KeyFunc returns the key identifying the client of a request.
+// Requests for which it returns an empty string are not rate limited.
+type KeyFunc func(ctx context.Context, request events.APIGatewayProxyRequest) string
+
+// KeyBySourceIP returns a KeyFunc that identifies clients by RequestContext.Identity.SourceIP.
+func KeyBySourceIP() KeyFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		return request.RequestContext.Identity.SourceIP
+	}
+}
+
+// KeyByAPIKey returns a KeyFunc that identifies clients by the API Gateway API key (RequestContext.Identity.APIKey).
+func KeyByAPIKey() KeyFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		return request.RequestContext.Identity.APIKey
+	}
+}
+
+// KeyByHeader returns a KeyFunc that identifies clients by the value of the named request header.
+func KeyByHeader(name string) KeyFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		return header.Request(&request).Get(name)
+	}
+}
+
+// KeyByClaim returns a KeyFunc that identifies clients by a claim of the API Gateway authorizer
+// (e.g. "sub"). The claim is looked up in RequestContext.Authorizer["claims"] first (Cognito and JWT authorizers),
+// then in RequestContext.Authorizer itself (Lambda authorizer context).
+func KeyByClaim(name string) KeyFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) string {
+		if claims, ok := request.RequestContext.Authorizer["claims"].(map[string]interface{}); ok {
+			if v, ok := claims[name]; ok && v != nil {
+				return fmt.Sprint(v)
+			}
+		}
+		if v, ok := request.RequestContext.Authorizer[name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+}
+
+// Config is the configuration for the RateLimit middleware.
+type Config struct {
+	store            Store
+	keyFunc          KeyFunc
+	now              func() time.Time
+	headers          bool
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the RateLimit configuration.
+type Option func(*Config)
+
+// WithStore sets the Store holding the rate limiting state.
+// By default, a MemoryStore created for the middleware is used.
+func WithStore(store Store) Option {
+	return func(c *Config) {
+		c.store = store
+	}
+}
+
+// WithKeyFunc sets the function identifying the client of a request.
+// By default, clients are identified by their source IP address.
+func WithKeyFunc(keyFunc KeyFunc) Option {
+	return func(c *Config) {
+		c.keyFunc = keyFunc
+	}
+}
+
+// WithClock sets the function returning the current time.
+// The time is also passed to the Store, so it governs the expiry of stored state.
+// By default, time.Now is used.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// WithHeaders enables or disables the RateLimit-* headers on allowed responses.
+// The headers are always set on 429 responses. By default, they are enabled.
+func WithHeaders(enable bool) Option {
+	return func(c *Config) {
+		c.headers = enable
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when the rate limit is exceeded.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// RateLimit creates middleware that throttles clients using the given Algorithm.
+//
+// Clients are identified by a KeyFunc (the source IP by default). When a client exceeds its limit,
+// the middleware returns 429 Too Many Requests with a Retry-After header without calling the next handler.
+// Responses carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+// (IETF draft "RateLimit header fields for HTTP"), with the reset expressed in seconds.
+//
+// If the Store fails, the error is returned and the next handler is not called.
+//
+// Example:
+//
+//	// 100 requests per minute per authenticated user
+//	ratelimit.RateLimit(ratelimit.SlidingWindow(100, time.Minute), ratelimit.WithKeyFunc(ratelimit.KeyByClaim("sub")))
+//
+//	// 10 requests per second with bursts of 20 per source IP
+//	ratelimit.RateLimit(ratelimit.TokenBucket(10, time.Second, 20))
+func RateLimit(algorithm Algorithm, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		keyFunc:          KeyBySourceIP(),
+		now:              time.Now,
+		headers:          true,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.store == nil {
+		config.store = NewMemoryStore()
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			key := config.keyFunc(ctx, request)
+			if key == "" {
+				return next(ctx, request)
+			}
+
+			result, err := algorithm.Take(ctx, config.store, key, config.now())
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, fmt.Errorf("ratelimit: %w", err)
+			}
+
+			if !result.Allowed {
+				response := events.APIGatewayProxyResponse{
+					StatusCode: http.StatusTooManyRequests,
+					Body:       config.errorBody,
+					Headers: map[string]string{
+						"Content-Type": config.errorContentType,
+						"Retry-After":  strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))),
+					},
+				}
+				setRateLimitHeaders(&response, result)
+				return response, nil
+			}
+
+			response, err := next(ctx, request)
+			if config.headers {
+				setRateLimitHeaders(&response, result)
+			}
+			return response, err
+		}
+	}
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers describing result.
+func setRateLimitHeaders(response *events.APIGatewayProxyResponse, result Result) {
+	header.Set(response, "RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set(response, "RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set(response, "RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+// ceilSeconds returns d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockHandler is a final handler for testing that always returns 200 OK.
+func mockHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "OK"}, nil
+}
+
+// createRequest creates a request from the given source IP.
+func createRequest(sourceIP string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: sourceIP, APIKey: "api-key-1"},
+			Authorizer: map[string]interface{}{
+				"principalId": "user-1",
+				"claims":      map[string]interface{}{"sub": "subject-1"},
+			},
+		},
+		Headers: map[string]string{"x-client-id": "client-1"},
+	}
+}
+
+// failingStore is a Store that always fails.
+type failingStore struct{}
+
+func (failingStore) Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(state State, found bool) State) error {
+	return errors.New("store unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	assert := assert.New(t)
+	clock := func() time.Time { return start }
+
+	handler := RateLimit(TokenBucket(1, time.Second, 2), WithClock(clock))(mockHandler)
+
+	// Allowed requests carry the RateLimit headers
+	response, err := handler(context.Background(), createRequest("192.0.2.1"))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("2", response.Headers["RateLimit-Limit"])
+	assert.Equal("1", response.Headers["RateLimit-Remaining"])
+	assert.Equal("1", response.Headers["RateLimit-Reset"])
+
+	response, err = handler(context.Background(), createRequest("192.0.2.1"))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal("0", response.Headers["RateLimit-Remaining"])
+
+	// The third request is throttled
+	response, err = handler(context.Background(), createRequest("192.0.2.1"))
+	assert.NoError(err)
+	assert.Equal(http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(defaultErrorBody, response.Body)
+	assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+	assert.Equal("1", response.Headers["Retry-After"])
+	assert.Equal("0", response.Headers["RateLimit-Remaining"])
+	assert.Equal("2", response.Headers["RateLimit-Reset"])
+
+	// Other clients are not affected
+	response, err = handler(context.Background(), createRequest("192.0.2.2"))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func TestRateLimit_KeyFuncs(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyFunc     KeyFunc
+		expectedKey string
+	}{
+		{name: "Source IP", keyFunc: KeyBySourceIP(), expectedKey: "192.0.2.1"},
+		{name: "API key", keyFunc: KeyByAPIKey(), expectedKey: "api-key-1"},
+		{name: "Header", keyFunc: KeyByHeader("X-Client-Id"), expectedKey: "client-1"},
+		{name: "Missing header", keyFunc: KeyByHeader("X-Missing"), expectedKey: ""},
+		{name: "Claim", keyFunc: KeyByClaim("sub"), expectedKey: "subject-1"},
+		{name: "Authorizer context", keyFunc: KeyByClaim("principalId"), expectedKey: "user-1"},
+		{name: "Missing claim", keyFunc: KeyByClaim("email"), expectedKey: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedKey, tt.keyFunc(context.Background(), createRequest("192.0.2.1")))
+		})
+	}
+}
+
+func TestRateLimit_EmptyKey(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := RateLimit(TokenBucket(1, time.Hour, 1), WithKeyFunc(KeyByHeader("X-Missing")))(mockHandler)
+
+	// Requests without a key are never throttled
+	for i := 0; i < 3; i++ {
+		response, err := handler(context.Background(), createRequest("192.0.2.1"))
+		assert.NoError(err)
+		assert.Equal(http.StatusOK, response.StatusCode)
+		assert.NotContains(response.Headers, "RateLimit-Limit")
+	}
+}
+
+func TestRateLimit_Options(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryStore()
+
+	handler := RateLimit(
+		SlidingWindow(1, time.Minute),
+		WithStore(store),
+		WithHeaders(false),
+		WithResponse("application/json", `{"error":"slow down"}`),
+	)(mockHandler)
+
+	response, err := handler(context.Background(), createRequest("192.0.2.1"))
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Nil(response.Headers)
+	assert.Len(store.entries, 1)
+
+	response, err = handler(context.Background(), createRequest("192.0.2.1"))
+	assert.NoError(err)
+	assert.Equal(http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(`{"error":"slow down"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+	assert.Equal("1", response.Headers["RateLimit-Limit"])
+}
+
+func TestRateLimit_StoreError(t *testing.T) {
+	assert := assert.New(t)
+	nextCalled := false
+
+	handler := RateLimit(TokenBucket(1, time.Second, 1), WithStore(failingStore{}))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		nextCalled = true
+		return mockHandler(ctx, request)
+	})
+
+	_, err := handler(context.Background(), createRequest("192.0.2.1"))
+	assert.ErrorContains(err, "store unavailable")
+	assert.False(nextCalled)
+}
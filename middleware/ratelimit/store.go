@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is the per-key state persisted by a Store.
+// Its fields are interpreted by the Algorithm that owns the key.
+type State struct {
+	// Value is the current token count (token bucket) or the request count of the current window (sliding window).
+	Value float64
+	// Previous is the request count of the previous window (sliding window).
+	Previous float64
+	// Time is the last refill time (token bucket) or the start of the current window (sliding window).
+	Time time.Time
+}
+
+// Store persists rate limiting state.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Update atomically loads the state stored under key and replaces it with the state returned by fn.
+	// found is false if no state is stored under the key yet.
+	// now is the time of the update, as seen by the middleware's clock (see WithClock).
+	// The stored state may be discarded once ttl has elapsed since now without further updates.
+	Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(state State, found bool) State) error
+}
+
+// memoryEntry is a state held by MemoryStore with its expiration time.
+type memoryEntry struct {
+	state     State
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-memory Store.
+// State is kept per Lambda execution environment, so limits are enforced per instance rather than globally.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(ctx context.Context, key string, now time.Time, ttl time.Duration, fn func(state State, found bool) State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now, ttl)
+
+	entry, found := s.entries[key]
+	if found && now.After(entry.expiresAt) {
+		entry, found = memoryEntry{}, false
+	}
+	s.entries[key] = memoryEntry{
+		state:     fn(entry.state, found),
+		expiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// sweep removes expired entries, at most once per ttl, so that idle keys do not accumulate.
+func (s *MemoryStore) sweep(now time.Time, ttl time.Duration) {
+	if now.Sub(s.lastSweep) < ttl {
+		return
+	}
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore_Update(t *testing.T) {
+	assert := assert.New(t)
+	now := start
+	store := NewMemoryStore()
+
+	increment := func(state State, found bool) State {
+		state.Value++
+		return state
+	}
+
+	// First update sees no state
+	err := store.Update(context.Background(), "key", now, time.Minute, func(state State, found bool) State {
+		assert.False(found)
+		return increment(state, found)
+	})
+	assert.NoError(err)
+
+	// Second update sees the stored state
+	err = store.Update(context.Background(), "key", now, time.Minute, func(state State, found bool) State {
+		assert.True(found)
+		assert.Equal(1.0, state.Value)
+		return increment(state, found)
+	})
+	assert.NoError(err)
+
+	// Expired state is discarded
+	now = now.Add(2 * time.Minute)
+	err = store.Update(context.Background(), "key", now, time.Minute, func(state State, found bool) State {
+		assert.False(found)
+		assert.Equal(State{}, state)
+		return state
+	})
+	assert.NoError(err)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	assert := assert.New(t)
+	now := start
+	store := NewMemoryStore()
+
+	noop := func(state State, found bool) State { return state }
+	for _, key := range []string{"a", "b", "c"} {
+		assert.NoError(store.Update(context.Background(), key, now, time.Minute, noop))
+	}
+	assert.Len(store.entries, 3)
+
+	now = now.Add(2 * time.Minute)
+	assert.NoError(store.Update(context.Background(), "d", now, time.Minute, noop))
+	assert.Len(store.entries, 1)
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store := NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = store.Update(context.Background(), "key", start, time.Minute, func(state State, found bool) State {
+				state.Value++
+				return state
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100.0, store.entries["key"].state.Value)
+}
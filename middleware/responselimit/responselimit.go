@@ -0,0 +1,292 @@
+package responselimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultMaxBytes is the default maximum size of the serialized response.
+	// Lambda rejects synchronous responses larger than 6 MB.
+	defaultMaxBytes = 6 << 20
+
+	// defaultErrorBody is the default response body when the response is too large.
+	defaultErrorBody = "Response Too Large"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+
+	// truncatedWarning is the Warning header added to truncated responses.
+	truncatedWarning = `199 - "Response truncated"`
+)
+
+// action is what the ResponseLimit middleware does with oversized responses.
+type action int
+
+const (
+	actionReject action = iota
+	actionTruncate
+	actionOffload
+)
+
+// OffloadMode is the form of the response returned in place of an offloaded body.
+type OffloadMode int
+
+const (
+	// OffloadRedirect returns 303 See Other with a Location header pointing to the stored body.
+	OffloadRedirect OffloadMode = iota
+
+	// OffloadPointer returns 200 OK with a JSON document describing the stored body:
+	// {"location": "<url>", "contentType": "<type>", "size": <bytes>}.
+	OffloadPointer
+)
+
+// Pointer is the JSON document returned with OffloadPointer.
+type Pointer struct {
+	Location    string `json:"location"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int    `json:"size"`
+}
+
+// Config is the configuration for the ResponseLimit middleware.
+type Config struct {
+	maxBytes         int
+	action           action
+	store            BlobStore
+	mode             OffloadMode
+	statusCode       int
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the ResponseLimit configuration.
+type Option func(*Config)
+
+// WithMaxBytes sets the maximum size of the response, as serialized to JSON by the Lambda runtime.
+// The default is 6 MiB.
+func WithMaxBytes(n int) Option {
+	return func(c *Config) {
+		c.maxBytes = n
+	}
+}
+
+// WithStatusCode sets the status code returned in place of an oversized response,
+// e.g. http.StatusRequestEntityTooLarge. The default is 500 Internal Server Error.
+func WithStatusCode(statusCode int) Option {
+	return func(c *Config) {
+		c.statusCode = statusCode
+	}
+}
+
+// WithTruncate truncates the body of oversized responses so that they fit, instead of rejecting them.
+// A `Warning: 199 - "Response truncated"` header is added to truncated responses.
+// Responses with a Content-Encoding header are never truncated, since a cut compressed body cannot be decoded;
+// they are replaced by the error response.
+func WithTruncate() Option {
+	return func(c *Config) {
+		c.action = actionTruncate
+	}
+}
+
+// WithOffload stores the body of oversized responses in store, instead of rejecting them,
+// and returns a response pointing to it in the given mode. ResponseLimit panics if store is nil.
+func WithOffload(store BlobStore, mode OffloadMode) Option {
+	return func(c *Config) {
+		c.action = actionOffload
+		c.store = store
+		c.mode = mode
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned in place of an oversized response.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// ResponseLimit creates middleware that guards against responses too large for Lambda.
+//
+// The size of the response returned by the handler is measured as serialized to JSON, the way the Lambda runtime
+// sends it. Oversized responses are replaced by an error response (500 Internal Server Error by default).
+// With WithTruncate, the body is truncated instead, and with WithOffload it is stored in a BlobStore
+// and the client is redirected to it or receives a pointer document.
+// Responses that still do not fit after truncation or offloading are replaced by the error response.
+//
+// ResponseLimit should be the outermost middleware, so that it measures the final response.
+// Responses returned together with an error are not modified.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, responselimit.ResponseLimit(responselimit.WithOffload(myS3Store, responselimit.OffloadRedirect)))
+func ResponseLimit(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		maxBytes:         defaultMaxBytes,
+		statusCode:       http.StatusInternalServerError,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.action == actionOffload && config.store == nil {
+		panic(errors.New("responselimit: nil offload store"))
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			size, err := responseSize(&response)
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, fmt.Errorf("responselimit: %w", err)
+			}
+			if size <= config.maxBytes {
+				return response, nil
+			}
+
+			switch config.action {
+			case actionTruncate:
+				if truncate(&response, config.maxBytes) {
+					return response, nil
+				}
+			case actionOffload:
+				offloaded, err := offload(ctx, &config, response)
+				if err != nil {
+					return events.APIGatewayProxyResponse{}, err
+				}
+				if size, err := responseSize(&offloaded); err == nil && size <= config.maxBytes {
+					return offloaded, nil
+				}
+			}
+
+			return events.APIGatewayProxyResponse{
+				StatusCode: config.statusCode,
+				Body:       config.errorBody,
+				Headers:    map[string]string{"Content-Type": config.errorContentType},
+			}, nil
+		}
+	}
+}
+
+// responseSize returns the size of response serialized to JSON.
+func responseSize(response *events.APIGatewayProxyResponse) (int, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// truncate truncates the body of response so that the serialized response fits in maxBytes.
+// It returns false if the response does not fit even with an empty body, or if the body is content-coded.
+func truncate(response *events.APIGatewayProxyResponse, maxBytes int) bool {
+	if header.Response(response).Get("Content-Encoding") != "" {
+		return false
+	}
+	header.Set(response, "Warning", truncatedWarning)
+	header.Del(response, "Content-Length")
+	body := response.Body
+	response.Body = ""
+	overhead, err := responseSize(response)
+	if err != nil || overhead > maxBytes {
+		return false
+	}
+
+	// Find the longest prefix whose escaped form fits
+	budget, n := maxBytes-overhead, 0
+	for n < len(body) {
+		r, width := utf8.DecodeRuneInString(body[n:])
+		size := escapedLen(r, width)
+		if size > budget {
+			break
+		}
+		budget -= size
+		n += width
+	}
+	if response.IsBase64Encoded {
+		// Keep whole base64 quanta so that the body stays decodable
+		n -= n % 4
+	}
+	response.Body = body[:n]
+	return true
+}
+
+// escapedLen returns the length of a rune of a string as escaped by encoding/json, or an upper bound
+// of it for invalid UTF-8. width is the number of bytes of the rune in the string.
+func escapedLen(r rune, width int) int {
+	switch {
+	case r == utf8.RuneError && width == 1:
+		// Invalid UTF-8 is replaced by U+FFFD, written raw or as \ufffd depending on the Go version;
+		// count the longer form so that the result is an upper bound
+		return 6
+	case r == '"' || r == '\\' || r == '\b' || r == '\f' || r == '\n' || r == '\r' || r == '\t':
+		return 2
+	case r < 0x20 || r == '<' || r == '>' || r == '&' || r == '\u2028' || r == '\u2029':
+		return 6
+	}
+	return width
+}
+
+// offload stores the body of response in the configured store and returns the response pointing to it.
+func offload(ctx context.Context, config *Config, response events.APIGatewayProxyResponse) (events.APIGatewayProxyResponse, error) {
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(response.Body); err != nil {
+			return events.APIGatewayProxyResponse{}, fmt.Errorf("responselimit: %w", err)
+		}
+	}
+	contentType := header.Response(&response).Get("Content-Type")
+
+	location, err := config.store.Put(ctx, newKey(), contentType, body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, fmt.Errorf("responselimit: %w", err)
+	}
+
+	// Headers describing the representation no longer apply; others (e.g. CORS, Set-Cookie) are kept
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Encoding", "ETag", "Last-Modified"} {
+		header.Del(&response, key)
+	}
+	response.IsBase64Encoded = false
+
+	switch config.mode {
+	case OffloadPointer:
+		data, err := json.Marshal(Pointer{Location: location, ContentType: contentType, Size: len(body)})
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, fmt.Errorf("responselimit: %w", err)
+		}
+		response.StatusCode = http.StatusOK
+		response.Body = string(data)
+		header.Set(&response, "Content-Type", "application/json")
+	default:
+		response.StatusCode = http.StatusSeeOther
+		response.Body = ""
+		header.Set(&response, "Location", location)
+	}
+	return response, nil
+}
+
+// newKey returns a new random key for an offloaded body.
+func newKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
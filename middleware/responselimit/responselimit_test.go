@@ -0,0 +1,236 @@
+package responselimit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// bodyHandler returns a handler that returns response.
+func bodyHandler(response events.APIGatewayProxyResponse) func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return response, nil
+	}
+}
+
+// size returns the serialized size of response.
+func size(t *testing.T, response events.APIGatewayProxyResponse) int {
+	data, err := json.Marshal(response)
+	assert.NoError(t, err)
+	return len(data)
+}
+
+func TestResponseLimit_WithinLimit(t *testing.T) {
+	original := events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: strings.Repeat("a", 100)}
+	response, err := ResponseLimit(WithMaxBytes(size(t, original)))(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, original, response)
+}
+
+func TestResponseLimit_Reject(t *testing.T) {
+	assert := assert.New(t)
+	original := events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: strings.Repeat("a", 1000)}
+
+	response, err := ResponseLimit(WithMaxBytes(500))(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(defaultErrorBody, response.Body)
+	assert.Equal(defaultErrorContentType, response.Headers["Content-Type"])
+
+	response, err = ResponseLimit(
+		WithMaxBytes(500),
+		WithStatusCode(http.StatusRequestEntityTooLarge),
+		WithResponse("application/json", `{"error":"too large"}`),
+	)(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusRequestEntityTooLarge, response.StatusCode)
+	assert.Equal(`{"error":"too large"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestResponseLimit_DefaultLimit(t *testing.T) {
+	original := events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: strings.Repeat("a", 6<<20)}
+	response, err := ResponseLimit()(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+}
+
+func TestResponseLimit_Truncate(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		isBase64Encoded bool
+		minBytes        int
+	}{
+		// The longest prefix is kept: one more character would not fit
+		{name: "ascii", body: strings.Repeat("a", 1000), minBytes: 500},
+		{name: "escaped characters", body: strings.Repeat("<\"\n\x01é\u2028", 200), minBytes: 500 - 5},
+		{name: "invalid UTF-8", body: strings.Repeat("a\xff", 500), minBytes: 300},
+		{name: "base64", body: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 1000))), isBase64Encoded: true, minBytes: 500 - 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			original := events.APIGatewayProxyResponse{
+				StatusCode:      http.StatusOK,
+				Headers:         map[string]string{"Content-Type": "text/plain", "Content-Length": "1000"},
+				Body:            tt.body,
+				IsBase64Encoded: tt.isBase64Encoded,
+			}
+			response, err := ResponseLimit(WithMaxBytes(500), WithTruncate())(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+			assert.NoError(err)
+			assert.Equal(http.StatusOK, response.StatusCode)
+			assert.Equal(truncatedWarning, response.Headers["Warning"])
+			assert.NotContains(response.Headers, "Content-Length")
+			assert.True(strings.HasPrefix(tt.body, response.Body))
+			assert.NotEmpty(response.Body)
+
+			s := size(t, response)
+			assert.LessOrEqual(s, 500)
+			assert.GreaterOrEqual(s, tt.minBytes)
+			if tt.isBase64Encoded {
+				_, err := base64.StdEncoding.DecodeString(response.Body)
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestResponseLimit_TruncateImpossible(t *testing.T) {
+	original := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"X-Large": strings.Repeat("a", 1000)},
+		Body:       "body",
+	}
+	response, err := ResponseLimit(WithMaxBytes(500), WithTruncate())(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+}
+
+func TestResponseLimit_TruncateEncoded(t *testing.T) {
+	// A prefix of a compressed body cannot be decoded
+	original := events.APIGatewayProxyResponse{
+		StatusCode:      http.StatusOK,
+		Headers:         map[string]string{"Content-Encoding": "gzip"},
+		Body:            base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 1000))),
+		IsBase64Encoded: true,
+	}
+	response, err := ResponseLimit(WithMaxBytes(500), WithTruncate())(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(t, defaultErrorBody, response.Body)
+}
+
+func TestResponseLimit_OffloadRedirect(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryBlobStore("https://blobs.example.com/")
+	original := events.APIGatewayProxyResponse{
+		StatusCode:        http.StatusOK,
+		Headers:           map[string]string{"content-type": "text/csv", "Access-Control-Allow-Origin": "*"},
+		MultiValueHeaders: map[string][]string{"Set-Cookie": {"a=1"}},
+		Body:              base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a,b\n", 500))),
+		IsBase64Encoded:   true,
+	}
+
+	response, err := ResponseLimit(WithMaxBytes(1000), WithOffload(store, OffloadRedirect))(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusSeeOther, response.StatusCode)
+	assert.Empty(response.Body)
+	assert.False(response.IsBase64Encoded)
+	assert.Equal("*", response.Headers["Access-Control-Allow-Origin"])
+	assert.Equal([]string{"a=1"}, response.MultiValueHeaders["Set-Cookie"])
+	assert.NotContains(response.Headers, "content-type")
+
+	location := response.Headers["Location"]
+	key, ok := strings.CutPrefix(location, "https://blobs.example.com/")
+	assert.True(ok)
+	blob, ok := store.Get(key)
+	assert.True(ok)
+	assert.Equal("text/csv", blob.ContentType)
+	assert.Equal(strings.Repeat("a,b\n", 500), string(blob.Body))
+}
+
+func TestResponseLimit_OffloadPointer(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryBlobStore("https://blobs.example.com/")
+	original := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json", "ETag": `"abc"`},
+		Body:       `[` + strings.Repeat(`1,`, 1000) + `1]`,
+	}
+
+	response, err := ResponseLimit(WithMaxBytes(1000), WithOffload(store, OffloadPointer))(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, response.StatusCode)
+	assert.Equal(map[string]string{"Content-Type": "application/json"}, response.Headers)
+
+	var pointer Pointer
+	assert.NoError(json.Unmarshal([]byte(response.Body), &pointer))
+	assert.Equal("application/json", pointer.ContentType)
+	assert.Equal(len(original.Body), pointer.Size)
+	blob, ok := store.Get(strings.TrimPrefix(pointer.Location, "https://blobs.example.com/"))
+	assert.True(ok)
+	assert.Equal(original.Body, string(blob.Body))
+}
+
+// failingStore is a BlobStore whose Put always fails.
+type failingStore struct{}
+
+func (failingStore) Put(ctx context.Context, key, contentType string, body []byte) (string, error) {
+	return "", errors.New("unavailable")
+}
+
+func TestResponseLimit_OffloadError(t *testing.T) {
+	original := events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: strings.Repeat("a", 1000)}
+	_, err := ResponseLimit(WithMaxBytes(500), WithOffload(failingStore{}, OffloadRedirect))(bodyHandler(original))(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorContains(t, err, "responselimit: unavailable")
+}
+
+func TestResponseLimit_NilOffloadStore(t *testing.T) {
+	assert.Panics(t, func() { ResponseLimit(WithOffload(nil, OffloadRedirect)) })
+}
+
+func TestResponseLimit_HandlerError(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	handler := ResponseLimit(WithMaxBytes(10))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{Body: strings.Repeat("a", 100)}, handlerErr
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Len(t, response.Body, 100)
+}
+
+// escapedStringLen sums escapedLen over the runes of s.
+func escapedStringLen(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		n += escapedLen(r, width)
+		i += width
+	}
+	return n
+}
+
+func TestEscapedLen(t *testing.T) {
+	inputs := []string{"abc", "<>&", "\"\\\b\f\n\r\t", "\x00\x1f\x7f", "é日本\U0001F600", "\u2028\u2029"}
+	for _, s := range inputs {
+		data, err := json.Marshal(s)
+		assert.NoError(t, err)
+		assert.Equal(t, len(data)-2, escapedStringLen(s), "%q", s)
+	}
+
+	// Invalid UTF-8 is counted as the longest possible replacement
+	for _, s := range []string{"\xff\xfe", "a\xe3\x81b"} {
+		data, err := json.Marshal(s)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, escapedStringLen(s), len(data)-2, "%q", s)
+	}
+}
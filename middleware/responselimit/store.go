@@ -0,0 +1,77 @@
+package responselimit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BlobStore stores response bodies too large to be returned by Lambda, e.g. in Amazon S3.
+type BlobStore interface {
+	// Put stores body under key and returns the URL from which the client can download it,
+	// e.g. a presigned S3 URL.
+	Put(ctx context.Context, key, contentType string, body []byte) (string, error)
+}
+
+// Blob is a body stored in a MemoryBlobStore.
+type Blob struct {
+	ContentType string
+	Body        []byte
+}
+
+// MemoryBlobStore is a BlobStore keeping bodies in memory. It is intended for tests.
+type MemoryBlobStore struct {
+	mu      sync.Mutex
+	blobs   map[string]Blob
+	baseURL string
+}
+
+// NewMemoryBlobStore returns an empty MemoryBlobStore. The URL of a body is baseURL followed by its key.
+func NewMemoryBlobStore(baseURL string) *MemoryBlobStore {
+	return &MemoryBlobStore{
+		blobs:   make(map[string]Blob),
+		baseURL: baseURL,
+	}
+}
+
+// Put implements BlobStore.
+func (s *MemoryBlobStore) Put(ctx context.Context, key, contentType string, body []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = Blob{ContentType: contentType, Body: body}
+	return s.baseURL + key, nil
+}
+
+// Get returns the body stored under key. The second return value is false if there is none.
+func (s *MemoryBlobStore) Get(key string) (Blob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	blob, ok := s.blobs[key]
+	return blob, ok
+}
+
+// FileBlobStore is a BlobStore writing bodies to files in a directory, e.g. for local development.
+// The returned URLs are file URLs, so they are only useful to clients running on the same host.
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore returns a FileBlobStore writing to dir, which must exist.
+func NewFileBlobStore(dir string) *FileBlobStore {
+	return &FileBlobStore{dir: dir}
+}
+
+// Put implements BlobStore.
+func (s *FileBlobStore) Put(ctx context.Context, key, contentType string, body []byte) (string, error) {
+	path, err := filepath.Abs(filepath.Join(s.dir, filepath.Base(key)))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, body, 0o600); err != nil {
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
@@ -0,0 +1,50 @@
+package responselimit
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBlobStore(t *testing.T) {
+	assert := assert.New(t)
+	s := NewMemoryBlobStore("https://blobs.example.com/")
+
+	location, err := s.Put(context.Background(), "key-1", "text/csv", []byte("a,b"))
+	assert.NoError(err)
+	assert.Equal("https://blobs.example.com/key-1", location)
+
+	blob, ok := s.Get("key-1")
+	assert.True(ok)
+	assert.Equal(Blob{ContentType: "text/csv", Body: []byte("a,b")}, blob)
+
+	_, ok = s.Get("missing")
+	assert.False(ok)
+}
+
+func TestFileBlobStore(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	s := NewFileBlobStore(dir)
+
+	location, err := s.Put(context.Background(), "../key-1", "text/csv", []byte("a,b"))
+	assert.NoError(err)
+
+	u, err := url.Parse(location)
+	assert.NoError(err)
+	assert.Equal("file", u.Scheme)
+	data, err := os.ReadFile(u.Path)
+	assert.NoError(err)
+	assert.Equal("a,b", string(data))
+	// Keys cannot escape the directory
+	assert.FileExists(dir + "/key-1")
+}
+
+func TestFileBlobStore_Error(t *testing.T) {
+	s := NewFileBlobStore(t.TempDir() + "/missing")
+	_, err := s.Put(context.Background(), "key-1", "text/csv", []byte("a,b"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+package secure
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/internal/route"
+)
+
+// NoncePlaceholder is replaced in the Content-Security-Policy header by the nonce generated for the request.
+const NoncePlaceholder = "{nonce}"
+
+// CtxKey is the default key type used to store the CSP nonce (string) within the context.
+type CtxKey struct{}
+
+// Policy is the set of security headers applied to a response. An empty field leaves the header unset.
+type Policy struct {
+	// StrictTransportSecurity is the value of the Strict-Transport-Security header.
+	StrictTransportSecurity string
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header. Every NoncePlaceholder
+	// is replaced by the nonce generated for the request (e.g. "script-src 'nonce-{nonce}'").
+	ContentSecurityPolicy string
+	// ContentSecurityPolicyReportOnly makes the policy reported only, with the
+	// Content-Security-Policy-Report-Only header.
+	ContentSecurityPolicyReportOnly bool
+	// ContentTypeOptions is the value of the X-Content-Type-Options header.
+	ContentTypeOptions string
+	// ReferrerPolicy is the value of the Referrer-Policy header.
+	ReferrerPolicy string
+	// PermissionsPolicy is the value of the Permissions-Policy header.
+	PermissionsPolicy string
+	// FrameOptions is the value of the X-Frame-Options header.
+	FrameOptions string
+	// CrossOriginOpenerPolicy is the value of the Cross-Origin-Opener-Policy header.
+	CrossOriginOpenerPolicy string
+	// CrossOriginEmbedderPolicy is the value of the Cross-Origin-Embedder-Policy header.
+	CrossOriginEmbedderPolicy string
+	// CrossOriginResourcePolicy is the value of the Cross-Origin-Resource-Policy header.
+	CrossOriginResourcePolicy string
+}
+
+// DefaultPolicy returns the policy applied by default. Modify a copy of it to customize a few headers.
+//
+// Cross-Origin-Embedder-Policy is not set, since require-corp blocks cross-origin resources
+// that do not opt in with CORS or Cross-Origin-Resource-Policy.
+func DefaultPolicy() Policy {
+	return Policy{
+		StrictTransportSecurity:   "max-age=63072000; includeSubDomains",
+		ContentSecurityPolicy:     "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'nonce-{nonce}'; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+		ContentTypeOptions:        "nosniff",
+		ReferrerPolicy:            "no-referrer",
+		PermissionsPolicy:         "camera=(), geolocation=(), microphone=()",
+		FrameOptions:              "DENY",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginResourcePolicy: "same-origin",
+	}
+}
+
+// Rule describes the policy applied to requests matching its conditions.
+// Empty conditions match any request.
+type Rule struct {
+	// Methods is the list of HTTP methods the rule applies to (e.g. "GET").
+	Methods []string
+	// Resource is a pattern matched against the API Gateway resource (e.g. "/docs/{proxy+}") of the request.
+	// The pattern syntax is that of path.Match. A pattern ending with "/**" matches every resource under the prefix.
+	Resource string
+	// Policy is the policy applied to matching requests, in place of the default policy.
+	Policy Policy
+}
+
+// Config is the configuration for the Secure middleware.
+type Config struct {
+	ctxKey any
+	policy Policy
+	rules  []Rule
+}
+
+// Option is a function type to modify the Secure configuration.
+type Option func(*Config)
+
+// WithCtxKey specifies the key of the CSP nonce to be set in the context.
+func WithCtxKey(ctxKey any) Option {
+	return func(c *Config) {
+		c.ctxKey = ctxKey
+	}
+}
+
+// WithPolicy sets the policy applied to requests not matching any rule. The default is DefaultPolicy().
+func WithPolicy(policy Policy) Option {
+	return func(c *Config) {
+		c.policy = policy
+	}
+}
+
+// WithRules sets per-route rules. The policy of the first rule matching the request replaces the default policy.
+func WithRules(rules ...Rule) Option {
+	return func(c *Config) {
+		c.rules = append(c.rules, rules...)
+	}
+}
+
+// Secure creates middleware that sets security headers on responses.
+//
+// The policy of the first matching rule (see WithRules), or the default policy (see WithPolicy), is applied.
+// Headers already set by the handler are never overridden, so a handler can relax a header for a single response.
+//
+// If the Content-Security-Policy contains NoncePlaceholder, a random nonce is generated for each request,
+// set in the context under CtxKey{} (or the key given with WithCtxKey) as a string, and substituted in the header.
+// Handlers use it in the nonce attribute of inline <script> and <style> elements.
+//
+// Place Secure before other middleware so that responses returned early by them, such as the 415 response
+// of contenttype or the 400 response of validate, also get the headers.
+// Responses returned together with an error are passed through unchanged.
+//
+// Example:
+//
+//	docs := secure.DefaultPolicy()
+//	docs.FrameOptions = "SAMEORIGIN"
+//
+//	handler := middleware.Use(myHandler,
+//	    secure.Secure(secure.WithRules(secure.Rule{Resource: "/docs/**", Policy: docs})),
+//	    contenttype.AllowContentType([]string{"application/json"}),
+//	)
+func Secure(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		ctxKey: CtxKey{},
+		policy: DefaultPolicy(),
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			policy := &config.policy
+			for i := range config.rules {
+				if route.Match(&request, config.rules[i].Methods, config.rules[i].Resource) {
+					policy = &config.rules[i].Policy
+					break
+				}
+			}
+
+			csp := policy.ContentSecurityPolicy
+			if strings.Contains(csp, NoncePlaceholder) {
+				nonce := newNonce()
+				csp = strings.ReplaceAll(csp, NoncePlaceholder, nonce)
+				ctx = context.WithValue(ctx, config.ctxKey, nonce)
+			}
+
+			response, err := next(ctx, request)
+			if err != nil {
+				return response, err
+			}
+
+			cspHeader := "Content-Security-Policy"
+			if policy.ContentSecurityPolicyReportOnly {
+				cspHeader = "Content-Security-Policy-Report-Only"
+			}
+			for _, h := range []struct{ key, value string }{
+				{"Strict-Transport-Security", policy.StrictTransportSecurity},
+				{cspHeader, csp},
+				{"X-Content-Type-Options", policy.ContentTypeOptions},
+				{"Referrer-Policy", policy.ReferrerPolicy},
+				{"Permissions-Policy", policy.PermissionsPolicy},
+				{"X-Frame-Options", policy.FrameOptions},
+				{"Cross-Origin-Opener-Policy", policy.CrossOriginOpenerPolicy},
+				{"Cross-Origin-Embedder-Policy", policy.CrossOriginEmbedderPolicy},
+				{"Cross-Origin-Resource-Policy", policy.CrossOriginResourcePolicy},
+			} {
+				if h.value != "" {
+					header.SetDefault(&response, h.key, h.value)
+				}
+			}
+
+			return response, nil
+		}
+	}
+}
+
+// newNonce returns a new random nonce, base64 encoded as required by CSP.
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
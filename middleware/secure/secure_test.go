@@ -0,0 +1,191 @@
+package secure
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/contenttype"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+// okHandler returns 200 OK with the nonce found in the context as body.
+func okHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	nonce, _ := ctx.Value(CtxKey{}).(string)
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: nonce}, nil
+}
+
+func TestSecure_Default(t *testing.T) {
+	assert := assert.New(t)
+	response, err := Secure()(okHandler)(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet})
+	assert.NoError(err)
+
+	nonce := response.Body
+	decoded, err := base64.StdEncoding.DecodeString(nonce)
+	assert.NoError(err)
+	assert.Len(decoded, 16)
+
+	policy := DefaultPolicy()
+	assert.Equal(map[string]string{
+		"Strict-Transport-Security":    policy.StrictTransportSecurity,
+		"Content-Security-Policy":      strings.ReplaceAll(policy.ContentSecurityPolicy, NoncePlaceholder, nonce),
+		"X-Content-Type-Options":       "nosniff",
+		"Referrer-Policy":              policy.ReferrerPolicy,
+		"Permissions-Policy":           policy.PermissionsPolicy,
+		"X-Frame-Options":              "DENY",
+		"Cross-Origin-Opener-Policy":   "same-origin",
+		"Cross-Origin-Resource-Policy": "same-origin",
+	}, response.Headers)
+	assert.Contains(response.Headers["Content-Security-Policy"], "'nonce-"+nonce+"'")
+}
+
+func TestSecure_NoncePerRequest(t *testing.T) {
+	handler := Secure()(okHandler)
+	first, _ := handler(context.Background(), events.APIGatewayProxyRequest{})
+	second, _ := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NotEqual(t, first.Body, second.Body)
+}
+
+func TestSecure_WithoutNonce(t *testing.T) {
+	assert := assert.New(t)
+	response, err := Secure(
+		WithPolicy(Policy{ContentSecurityPolicy: "default-src 'none'"}),
+	)(okHandler)(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Empty(response.Body)
+	assert.Equal(map[string]string{"Content-Security-Policy": "default-src 'none'"}, response.Headers)
+}
+
+func TestSecure_ReportOnly(t *testing.T) {
+	response, err := Secure(
+		WithPolicy(Policy{ContentSecurityPolicy: "default-src 'self'", ContentSecurityPolicyReportOnly: true}),
+	)(okHandler)(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Content-Security-Policy-Report-Only": "default-src 'self'"}, response.Headers)
+}
+
+func TestSecure_WithCtxKey(t *testing.T) {
+	type key struct{}
+	handler := Secure(WithCtxKey(key{}))(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		nonce, _ := ctx.Value(key{}).(string)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: nonce}, nil
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response.Body)
+	assert.Contains(t, response.Headers["Content-Security-Policy"], response.Body)
+}
+
+func TestSecure_Rules(t *testing.T) {
+	docs := DefaultPolicy()
+	docs.FrameOptions = "SAMEORIGIN"
+	docs.ContentSecurityPolicy = ""
+	api := Policy{ContentTypeOptions: "nosniff"}
+
+	handler := Secure(WithRules(
+		Rule{Resource: "/docs/**", Policy: docs},
+		Rule{Methods: []string{"POST"}, Resource: "/orders", Policy: api},
+	))(okHandler)
+
+	tests := []struct {
+		name         string
+		method       string
+		resource     string
+		frameOptions string
+		csp          bool
+	}{
+		{"Docs root", http.MethodGet, "/docs", "SAMEORIGIN", false},
+		{"Docs page", http.MethodGet, "/docs/{proxy+}", "SAMEORIGIN", false},
+		{"Orders POST", http.MethodPost, "/orders", "", false},
+		{"Orders GET falls back to default", http.MethodGet, "/orders", "DENY", true},
+		{"Other resource", http.MethodGet, "/documents", "DENY", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: tt.method, Resource: tt.resource})
+			assert.NoError(t, err)
+			assert.Equal(t, "nosniff", response.Headers["X-Content-Type-Options"])
+			assert.Equal(t, tt.frameOptions, response.Headers["X-Frame-Options"])
+			_, ok := response.Headers["Content-Security-Policy"]
+			assert.Equal(t, tt.csp, ok)
+			assert.Equal(t, tt.csp, response.Body != "")
+		})
+	}
+}
+
+func TestSecure_KeepsHandlerHeaders(t *testing.T) {
+	assert := assert.New(t)
+	handler := Secure()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{
+			StatusCode:        http.StatusOK,
+			Headers:           map[string]string{"x-frame-options": "SAMEORIGIN"},
+			MultiValueHeaders: map[string][]string{"Content-Security-Policy": {"frame-ancestors 'self'"}},
+		}, nil
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.NoError(err)
+	assert.Equal("SAMEORIGIN", response.Headers["x-frame-options"])
+	assert.NotContains(response.Headers, "X-Frame-Options")
+	assert.NotContains(response.Headers, "Content-Security-Policy")
+	assert.Equal([]string{"frame-ancestors 'self'"}, response.MultiValueHeaders["Content-Security-Policy"])
+	assert.Equal("nosniff", response.Headers["X-Content-Type-Options"])
+}
+
+func TestSecure_HandlerError(t *testing.T) {
+	handlerErr := errors.New("handler error")
+	handler := Secure()(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, handlerErr
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Nil(t, response.Headers)
+}
+
+func TestSecure_EarlyResponses(t *testing.T) {
+	type body struct {
+		Name string `json:"name" validate:"required"`
+	}
+	handler := middleware.Use(okHandler,
+		Secure(),
+		contenttype.AllowContentType([]string{"application/json"}),
+		validate.Validate[body](),
+	)
+
+	tests := []struct {
+		name       string
+		request    events.APIGatewayProxyRequest
+		statusCode int
+	}{
+		{
+			"Rejected by contenttype",
+			events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Headers: map[string]string{"Content-Type": "text/plain"}, Body: "x"},
+			http.StatusUnsupportedMediaType,
+		},
+		{
+			"Rejected by validate",
+			events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Headers: map[string]string{"Content-Type": "application/json"}, Body: `{}`},
+			http.StatusBadRequest,
+		},
+		{
+			"Accepted",
+			events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"name":"a"}`},
+			http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := handler(context.Background(), tt.request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.statusCode, response.StatusCode)
+			assert.Equal(t, "nosniff", response.Headers["X-Content-Type-Options"])
+			assert.Equal(t, "DENY", response.Headers["X-Frame-Options"])
+			assert.Contains(t, response.Headers, "Content-Security-Policy")
+		})
+	}
+}
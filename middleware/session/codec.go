@@ -0,0 +1,152 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// errInvalidCookie is returned when a cookie cannot be authenticated or decoded with any key.
+	errInvalidCookie = errors.New("session: invalid cookie")
+
+	// errExpired is returned when the session encoded in a cookie is expired.
+	errExpired = errors.New("session: expired")
+
+	// errTooLarge is returned when the encoded cookie exceeds the size browsers accept.
+	errTooLarge = errors.New("session: cookie too large")
+)
+
+const (
+	// maxCookieSize is the maximum size of a cookie value accepted by browsers.
+	maxCookieSize = 4096
+
+	// minHashKeySize is the minimum size of a Key.Hash.
+	minHashKeySize = 32
+)
+
+// Key is a key pair used to protect session cookies.
+type Key struct {
+	// Hash is the HMAC-SHA256 key authenticating the cookie. It must be at least 32 random bytes.
+	Hash []byte
+	// Encryption is the AES key (16, 24 or 32 bytes) encrypting the cookie with AES-GCM.
+	// If nil, the cookie is signed but not encrypted.
+	Encryption []byte
+}
+
+// payload is the content of a session cookie.
+type payload struct {
+	Values    map[string]any `json:"v,omitempty"`
+	ID        string         `json:"i,omitempty"`
+	ExpiresAt int64          `json:"e,omitempty"`
+}
+
+// codec encodes and decodes cookie values with rotating keys.
+// The first key is used to encode, and every key is tried to decode.
+type codec struct {
+	keys  []Key
+	aeads []cipher.AEAD
+}
+
+// newCodec returns a codec for keys. It panics if a hash key is shorter than 32 bytes
+// or an encryption key is invalid.
+func newCodec(keys []Key) *codec {
+	c := &codec{keys: keys, aeads: make([]cipher.AEAD, len(keys))}
+	for i, key := range keys {
+		if len(key.Hash) < minHashKeySize {
+			panic(fmt.Errorf("session: hash key must be at least %d bytes", minHashKeySize))
+		}
+		if key.Encryption == nil {
+			continue
+		}
+		block, err := aes.NewCipher(key.Encryption)
+		if err != nil {
+			panic(fmt.Errorf("session: %w", err))
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			panic(fmt.Errorf("session: %w", err))
+		}
+		c.aeads[i] = aead
+	}
+	return c
+}
+
+// encode serializes p into a cookie value bound to name.
+func (c *codec) encode(name string, p payload) (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("session: %w", err)
+	}
+	if aead := c.aeads[0]; aead != nil {
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("session: %w", err)
+		}
+		data = aead.Seal(nonce, nonce, data, []byte(name))
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	value := encoded + "." + base64.RawURLEncoding.EncodeToString(mac(c.keys[0].Hash, name, encoded))
+	if len(name)+1+len(value) > maxCookieSize {
+		return "", errTooLarge
+	}
+	return value, nil
+}
+
+// decode authenticates and deserializes a cookie value bound to name, and checks its expiry.
+func (c *codec) decode(name, value string, now time.Time) (payload, error) {
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return payload{}, errInvalidCookie
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return payload{}, errInvalidCookie
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return payload{}, errInvalidCookie
+	}
+
+	for i, key := range c.keys {
+		if !hmac.Equal(sig, mac(key.Hash, name, encoded)) {
+			continue
+		}
+		plain := data
+		if aead := c.aeads[i]; aead != nil {
+			if len(data) < aead.NonceSize() {
+				return payload{}, errInvalidCookie
+			}
+			nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+			if plain, err = aead.Open(nil, nonce, ciphertext, []byte(name)); err != nil {
+				return payload{}, errInvalidCookie
+			}
+		}
+		var p payload
+		if err := json.Unmarshal(plain, &p); err != nil {
+			return payload{}, errInvalidCookie
+		}
+		if p.ExpiresAt != 0 && !now.Before(time.Unix(p.ExpiresAt, 0)) {
+			return payload{}, errExpired
+		}
+		return p, nil
+	}
+	return payload{}, errInvalidCookie
+}
+
+// mac returns the HMAC-SHA256 of the cookie name and value.
+func mac(key []byte, name, value string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{'|'})
+	h.Write([]byte(value))
+	return h.Sum(nil)
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testHashKey       = bytes.Repeat([]byte{'h'}, 32)
+	testEncryptionKey = bytes.Repeat([]byte{'e'}, 32)
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name string
+		key  Key
+	}{
+		{name: "signed", key: Key{Hash: testHashKey}},
+		{name: "encrypted", key: Key{Hash: testHashKey, Encryption: testEncryptionKey}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			c := newCodec([]Key{tt.key})
+			value, err := c.encode("session", payload{Values: map[string]any{"user": "alice"}, ExpiresAt: now.Add(time.Hour).Unix()})
+			assert.NoError(err)
+
+			p, err := c.decode("session", value, now)
+			assert.NoError(err)
+			assert.Equal(map[string]any{"user": "alice"}, p.Values)
+
+			// The cookie is bound to its name
+			_, err = c.decode("other", value, now)
+			assert.ErrorIs(err, errInvalidCookie)
+
+			// Expired
+			_, err = c.decode("session", value, now.Add(time.Hour))
+			assert.ErrorIs(err, errExpired)
+		})
+	}
+}
+
+func TestCodec_Encrypted(t *testing.T) {
+	c := newCodec([]Key{{Hash: testHashKey, Encryption: testEncryptionKey}})
+	value, err := c.encode("session", payload{Values: map[string]any{"user": "alice"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, value, "alice")
+	assert.NotContains(t, value, "YWxpY2")
+}
+
+func TestCodec_Tampered(t *testing.T) {
+	assert := assert.New(t)
+	c := newCodec([]Key{{Hash: testHashKey}})
+	value, err := c.encode("session", payload{Values: map[string]any{"admin": false}})
+	assert.NoError(err)
+
+	data, sig, _ := strings.Cut(value, ".")
+	tests := []string{
+		"",
+		data,
+		data + ".",
+		data + "." + sig + "x",
+		"e30." + sig,
+		"!!." + sig,
+	}
+	for _, v := range tests {
+		_, err := c.decode("session", v, time.Now())
+		assert.ErrorIs(err, errInvalidCookie, v)
+	}
+}
+
+func TestCodec_Rotation(t *testing.T) {
+	assert := assert.New(t)
+	oldKey := Key{Hash: bytes.Repeat([]byte{'o'}, 32), Encryption: bytes.Repeat([]byte{'p'}, 16)}
+	newKey := Key{Hash: testHashKey, Encryption: testEncryptionKey}
+
+	value, err := newCodec([]Key{oldKey}).encode("session", payload{ID: "id-1"})
+	assert.NoError(err)
+
+	// A cookie encoded with the old key is still accepted after rotation
+	p, err := newCodec([]Key{newKey, oldKey}).decode("session", value, time.Now())
+	assert.NoError(err)
+	assert.Equal("id-1", p.ID)
+
+	// ...but not once the old key is removed
+	_, err = newCodec([]Key{newKey}).decode("session", value, time.Now())
+	assert.ErrorIs(err, errInvalidCookie)
+}
+
+func TestCodec_TooLarge(t *testing.T) {
+	c := newCodec([]Key{{Hash: testHashKey}})
+	_, err := c.encode("session", payload{Values: map[string]any{"data": strings.Repeat("x", maxCookieSize)}})
+	assert.ErrorIs(t, err, errTooLarge)
+}
+
+func TestNewCodec_InvalidKey(t *testing.T) {
+	assert.Panics(t, func() {
+		newCodec([]Key{{Hash: testHashKey, Encryption: []byte("short")}})
+	})
+	assert.Panics(t, func() {
+		newCodec([]Key{{Encryption: testEncryptionKey}})
+	})
+}
@@ -0,0 +1,317 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/cookie"
+)
+
+const (
+	// defaultCookieName is the default name of the session cookie.
+	defaultCookieName = "session"
+
+	// defaultMaxAge is the default lifetime of a session.
+	defaultMaxAge = 24 * time.Hour
+)
+
+// CtxKey is the key type used to store the *Session within the context. Use FromContext to read it.
+type CtxKey struct{}
+
+// Session holds the values of a session. It is safe for concurrent use.
+//
+// Values are serialized with encoding/json, so they are read back as the types produced by json.Unmarshal
+// into an any (e.g. numbers become float64).
+type Session struct {
+	mu        sync.Mutex
+	id        string
+	oldID     string
+	values    map[string]any
+	isNew     bool
+	changed   bool
+	destroyed bool
+}
+
+// FromContext returns the session of the request, or nil if the Sessions middleware did not run.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(CtxKey{}).(*Session)
+	return s
+}
+
+// Get returns the value stored under key, or nil.
+func (s *Session) Get(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+// Set stores value under key.
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values == nil {
+		s.values = make(map[string]any)
+	}
+	s.values[key] = value
+	s.changed = true
+}
+
+// Delete removes the value stored under key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.changed = true
+	}
+}
+
+// Destroy removes all values and expires the session cookie.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = nil
+	s.destroyed = true
+}
+
+// RenewID gives the session a new ID, e.g. after login to prevent session fixation.
+// It only has an effect with WithStore.
+func (s *Session) RenewID() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oldID == "" && !s.isNew {
+		s.oldID = s.id
+	}
+	s.id = newID()
+	s.changed = true
+}
+
+// ID returns the session ID. It is empty unless WithStore is used.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// IsNew reports whether the request carried no valid session.
+func (s *Session) IsNew() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isNew
+}
+
+// Config is the configuration for the Sessions middleware.
+type Config struct {
+	keys   []Key
+	store  Store
+	cookie http.Cookie
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+// Option is a function type to modify the Sessions configuration.
+type Option func(*Config)
+
+// WithKeys sets the keys protecting the session cookie. The first key is used to encode cookies,
+// and all keys are tried to decode them, so keys can be rotated by prepending a new key.
+// At least one key is required, and Sessions panics if a hash key is shorter than 32 bytes.
+func WithKeys(keys ...Key) Option {
+	return func(c *Config) {
+		c.keys = append(c.keys, keys...)
+	}
+}
+
+// WithStore keeps session values in store. The cookie then only holds the signed session ID.
+// By default, the values are stored in the cookie itself.
+func WithStore(store Store) Option {
+	return func(c *Config) {
+		c.store = store
+	}
+}
+
+// WithCookie sets the session cookie attributes. Its Name, Path, Domain, Secure, HttpOnly and SameSite fields
+// are used. The default is a cookie named "session" with Path=/, Secure, HttpOnly and SameSite=Lax.
+func WithCookie(cookie http.Cookie) Option {
+	return func(c *Config) {
+		c.cookie = cookie
+	}
+}
+
+// WithMaxAge sets the lifetime of sessions, counted from their last change. The default is 24 hours.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(c *Config) {
+		c.maxAge = maxAge
+	}
+}
+
+// WithClock sets the function returning the current time, used to check session expiry.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// Sessions creates middleware that loads the session of the request and saves it after the handler.
+//
+// The session is read from a cookie authenticated with HMAC-SHA256 and, if the key has an encryption key,
+// encrypted with AES-GCM. With WithStore, the cookie only holds the session ID and values are kept in the store.
+// Missing, tampered or expired cookies yield a new empty session.
+//
+// The *Session is available to the handler with FromContext. When the handler changed or destroyed it,
+// the session is saved and a Set-Cookie header is appended to the response's MultiValueHeaders;
+// unchanged sessions produce no Set-Cookie header. Responses returned together with an error are not modified.
+//
+// Sessions panics if no key is given or an encryption key is invalid.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler, session.Sessions(session.WithKeys(session.Key{Hash: hashKey, Encryption: aesKey})))
+//
+//	// In the handler
+//	s := session.FromContext(ctx)
+//	s.Set("userId", "user-1")
+func Sessions(opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		cookie: http.Cookie{
+			Name:     defaultCookieName,
+			Path:     "/",
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		maxAge: defaultMaxAge,
+		now:    time.Now,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if len(config.keys) == 0 {
+		panic(errors.New("session: at least one key is required"))
+	}
+	codec := newCodec(config.keys)
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			s, err := load(ctx, &config, codec, &request)
+			if err != nil {
+				return events.APIGatewayProxyResponse{}, err
+			}
+
+			response, err := next(context.WithValue(ctx, CtxKey{}, s), request)
+			if err != nil {
+				return response, err
+			}
+
+			if err := save(ctx, &config, codec, s, &response); err != nil {
+				return events.APIGatewayProxyResponse{}, err
+			}
+			return response, nil
+		}
+	}
+}
+
+// load reads the session of request.
+func load(ctx context.Context, config *Config, codec *codec, request *events.APIGatewayProxyRequest) (*Session, error) {
+	c, err := cookie.Get(request, config.cookie.Name)
+	if err != nil || c.Value == "" {
+		return newSession(config), nil
+	}
+	p, err := codec.decode(config.cookie.Name, c.Value, config.now())
+	if err != nil {
+		return newSession(config), nil
+	}
+	if config.store == nil {
+		return &Session{values: p.Values}, nil
+	}
+
+	if p.ID == "" {
+		return newSession(config), nil
+	}
+	values, ok, err := config.store.Load(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	if !ok {
+		return newSession(config), nil
+	}
+	return &Session{id: p.ID, values: values}, nil
+}
+
+// newSession returns a new empty session.
+func newSession(config *Config) *Session {
+	s := &Session{isNew: true}
+	if config.store != nil {
+		s.id = newID()
+	}
+	return s
+}
+
+// save persists a changed or destroyed session and sets the cookie on response.
+func save(ctx context.Context, config *Config, codec *codec, s *Session, response *events.APIGatewayProxyResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := config.cookie
+	if s.destroyed {
+		if config.store != nil && !s.isNew {
+			for _, id := range []string{s.oldID, s.id} {
+				if id == "" {
+					continue
+				}
+				if err := config.store.Delete(ctx, id); err != nil {
+					return fmt.Errorf("session: %w", err)
+				}
+			}
+		}
+		if !s.isNew {
+			c.MaxAge = -1
+			cookie.Set(response, &c)
+		}
+		return nil
+	}
+	if !s.changed {
+		return nil
+	}
+
+	expiresAt := config.now().Add(config.maxAge)
+	p := payload{ExpiresAt: expiresAt.Unix()}
+	if config.store != nil {
+		if err := config.store.Save(ctx, s.id, s.values, config.maxAge); err != nil {
+			return fmt.Errorf("session: %w", err)
+		}
+		if s.oldID != "" {
+			if err := config.store.Delete(ctx, s.oldID); err != nil {
+				return fmt.Errorf("session: %w", err)
+			}
+		}
+		p.ID = s.id
+	} else {
+		p.Values = s.values
+	}
+
+	value, err := codec.encode(config.cookie.Name, p)
+	if err != nil {
+		return err
+	}
+	c.Value = value
+	c.MaxAge = int(config.maxAge / time.Second)
+	cookie.Set(response, &c)
+	return nil
+}
+
+// newID returns a new random session ID.
+func newID() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,291 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// handlerFunc is the signature of the handlers under test.
+type handlerFunc = func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// sessionHandler returns a handler that calls fn with the session and returns 200 OK.
+func sessionHandler(fn func(s *Session)) handlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		fn(FromContext(ctx))
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}
+}
+
+// createRequest creates a request carrying the given cookie header, if any.
+func createRequest(cookie string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet}
+	if cookie != "" {
+		request.Headers = map[string]string{"cookie": cookie}
+	}
+	return request
+}
+
+// responseCookie returns the single session cookie set on response.
+func responseCookie(t *testing.T, response events.APIGatewayProxyResponse) *http.Cookie {
+	t.Helper()
+	cookies := response.MultiValueHeaders["Set-Cookie"]
+	if !assert.Len(t, cookies, 1) {
+		t.FailNow()
+	}
+	cookie, err := http.ParseSetCookie(cookies[0])
+	assert.NoError(t, err)
+	return cookie
+}
+
+func TestSessions_Cookie(t *testing.T) {
+	assert := assert.New(t)
+	mw := Sessions(WithKeys(Key{Hash: testHashKey, Encryption: testEncryptionKey}))
+
+	// A new session is created and saved when it changes
+	response, err := mw(sessionHandler(func(s *Session) {
+		assert.True(s.IsNew())
+		assert.Empty(s.ID())
+		assert.Nil(s.Get("user"))
+		s.Set("user", "alice")
+		s.Set("visits", 1)
+	}))(context.Background(), createRequest(""))
+	assert.NoError(err)
+	cookie := responseCookie(t, response)
+	assert.Equal("session", cookie.Name)
+	assert.Equal("/", cookie.Path)
+	assert.Equal(86400, cookie.MaxAge)
+	assert.True(cookie.Secure)
+	assert.True(cookie.HttpOnly)
+	assert.Equal(http.SameSiteLaxMode, cookie.SameSite)
+
+	// The session is restored from the cookie; values round-trip through JSON
+	response, err = mw(sessionHandler(func(s *Session) {
+		assert.False(s.IsNew())
+		assert.Equal("alice", s.Get("user"))
+		assert.Equal(float64(1), s.Get("visits"))
+	}))(context.Background(), createRequest("other=x; session="+cookie.Value))
+	assert.NoError(err)
+	// Unchanged sessions are not saved
+	assert.Empty(response.MultiValueHeaders["Set-Cookie"])
+
+	// Deleting a value saves the session
+	response, err = mw(sessionHandler(func(s *Session) {
+		s.Delete("user")
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+	cookie = responseCookie(t, response)
+
+	_, err = mw(sessionHandler(func(s *Session) {
+		assert.Nil(s.Get("user"))
+		assert.Equal(float64(1), s.Get("visits"))
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+}
+
+func TestSessions_InvalidCookie(t *testing.T) {
+	assert := assert.New(t)
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}))
+	other := newCodec([]Key{{Hash: bytes.Repeat([]byte{'x'}, 32)}})
+	forged, err := other.encode("session", payload{Values: map[string]any{"admin": true}})
+	assert.NoError(err)
+
+	for _, value := range []string{"garbage", forged} {
+		response, err := mw(sessionHandler(func(s *Session) {
+			assert.True(s.IsNew())
+			assert.Nil(s.Get("admin"))
+		}))(context.Background(), createRequest("session="+value))
+		assert.NoError(err)
+		assert.Empty(response.MultiValueHeaders["Set-Cookie"])
+	}
+}
+
+func TestSessions_Expired(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Unix(1700000000, 0)
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}), WithMaxAge(time.Hour), WithClock(func() time.Time { return now }))
+
+	response, err := mw(sessionHandler(func(s *Session) {
+		s.Set("user", "alice")
+	}))(context.Background(), createRequest(""))
+	assert.NoError(err)
+	cookie := responseCookie(t, response)
+	assert.Equal(3600, cookie.MaxAge)
+
+	now = now.Add(time.Hour)
+	_, err = mw(sessionHandler(func(s *Session) {
+		assert.True(s.IsNew())
+		assert.Nil(s.Get("user"))
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+}
+
+func TestSessions_Destroy(t *testing.T) {
+	assert := assert.New(t)
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}))
+
+	response, err := mw(sessionHandler(func(s *Session) {
+		s.Set("user", "alice")
+	}))(context.Background(), createRequest(""))
+	assert.NoError(err)
+	cookie := responseCookie(t, response)
+
+	response, err = mw(sessionHandler(func(s *Session) {
+		s.Destroy()
+		assert.Nil(s.Get("user"))
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+	assert.Equal([]string{"session=; Path=/; Max-Age=0; HttpOnly; Secure; SameSite=Lax"}, response.MultiValueHeaders["Set-Cookie"])
+
+	// Destroying a new session sets no cookie
+	response, err = mw(sessionHandler(func(s *Session) {
+		s.Destroy()
+	}))(context.Background(), createRequest(""))
+	assert.NoError(err)
+	assert.Empty(response.MultiValueHeaders["Set-Cookie"])
+}
+
+func TestSessions_Store(t *testing.T) {
+	assert := assert.New(t)
+	store := NewMemoryStore()
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}), WithStore(store))
+
+	var id string
+	response, err := mw(sessionHandler(func(s *Session) {
+		assert.True(s.IsNew())
+		id = s.ID()
+		s.Set("user", "alice")
+	}))(context.Background(), createRequest(""))
+	assert.NoError(err)
+	assert.NotEmpty(id)
+	cookie := responseCookie(t, response)
+	assert.NotContains(cookie.Value, "alice")
+
+	values, ok, err := store.Load(context.Background(), id)
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal(map[string]any{"user": "alice"}, values)
+
+	// Changing a stored session does not reissue the cookie
+	response, err = mw(sessionHandler(func(s *Session) {
+		assert.False(s.IsNew())
+		assert.Equal(id, s.ID())
+		assert.Equal("alice", s.Get("user"))
+		s.Set("role", "admin")
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+	assert.Len(response.MultiValueHeaders["Set-Cookie"], 1)
+
+	// Renewing the ID moves the session and deletes the old ID
+	var renewed string
+	response, err = mw(sessionHandler(func(s *Session) {
+		s.RenewID()
+		renewed = s.ID()
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+	assert.NotEqual(id, renewed)
+	newCookie := responseCookie(t, response)
+	_, ok, _ = store.Load(context.Background(), id)
+	assert.False(ok)
+	values, ok, _ = store.Load(context.Background(), renewed)
+	assert.True(ok)
+	assert.Equal(map[string]any{"user": "alice", "role": "admin"}, values)
+
+	// The old cookie no longer loads the session
+	_, err = mw(sessionHandler(func(s *Session) {
+		assert.True(s.IsNew())
+	}))(context.Background(), createRequest("session="+cookie.Value))
+	assert.NoError(err)
+
+	// Destroying deletes the stored session
+	response, err = mw(sessionHandler(func(s *Session) {
+		s.Destroy()
+	}))(context.Background(), createRequest("session="+newCookie.Value))
+	assert.NoError(err)
+	assert.Equal(-1, responseCookie(t, response).MaxAge)
+	_, ok, _ = store.Load(context.Background(), renewed)
+	assert.False(ok)
+}
+
+// failingStore is a Store whose operations always fail.
+type failingStore struct{}
+
+func (failingStore) Load(ctx context.Context, id string) (map[string]any, bool, error) {
+	return nil, false, errors.New("unavailable")
+}
+
+func (failingStore) Save(ctx context.Context, id string, values map[string]any, ttl time.Duration) error {
+	return errors.New("unavailable")
+}
+
+func (failingStore) Delete(ctx context.Context, id string) error {
+	return errors.New("unavailable")
+}
+
+func TestSessions_StoreError(t *testing.T) {
+	assert := assert.New(t)
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}), WithStore(failingStore{}))
+
+	_, err := mw(sessionHandler(func(s *Session) {
+		s.Set("user", "alice")
+	}))(context.Background(), createRequest(""))
+	assert.ErrorContains(err, "session: unavailable")
+
+	value, err := newCodec([]Key{{Hash: testHashKey}}).encode("session", payload{ID: "id-1"})
+	assert.NoError(err)
+	_, err = mw(sessionHandler(func(s *Session) {}))(context.Background(), createRequest("session="+value))
+	assert.ErrorContains(err, "session: unavailable")
+}
+
+func TestSessions_HandlerError(t *testing.T) {
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}))
+	handlerErr := errors.New("handler error")
+	response, err := mw(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		FromContext(ctx).Set("user", "alice")
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusInternalServerError}, handlerErr
+	})(context.Background(), createRequest(""))
+	assert.ErrorIs(t, err, handlerErr)
+	assert.Empty(t, response.MultiValueHeaders["Set-Cookie"])
+}
+
+func TestSessions_KeepsExistingSetCookie(t *testing.T) {
+	mw := Sessions(WithKeys(Key{Hash: testHashKey}), WithCookie(http.Cookie{Name: "sid", Path: "/app"}))
+	response, err := mw(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		FromContext(ctx).Set("user", "alice")
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Set-Cookie": "theme=dark"},
+		}, nil
+	})(context.Background(), createRequest(""))
+	assert.NoError(t, err)
+	cookies := response.MultiValueHeaders["Set-Cookie"]
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, "theme=dark", cookies[0])
+	assert.Regexp(t, `^sid=[^;]+; Path=/app; Max-Age=86400$`, cookies[1])
+	assert.NotContains(t, response.Headers, "Set-Cookie")
+}
+
+func TestSessions_NoKeys(t *testing.T) {
+	assert.Panics(t, func() {
+		Sessions()
+	})
+}
+
+func TestSessions_WeakKeys(t *testing.T) {
+	// Cookies signed with an empty or short hash key could be forged
+	assert.Panics(t, func() { Sessions(WithKeys(Key{})) })
+	assert.Panics(t, func() { Sessions(WithKeys(Key{Hash: []byte{}})) })
+	assert.Panics(t, func() { Sessions(WithKeys(Key{Hash: bytes.Repeat([]byte{'k'}, 31)})) })
+	assert.Panics(t, func() { Sessions(WithKeys(Key{Hash: testHashKey}, Key{Encryption: testEncryptionKey})) })
+	assert.NotPanics(t, func() { Sessions(WithKeys(Key{Hash: testHashKey})) })
+}
+
+func TestFromContext_None(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+}
@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Store stores session values on the server, keyed by session ID.
+type Store interface {
+	// Load returns the values of the session. The second return value is false if there is none.
+	Load(ctx context.Context, id string) (map[string]any, bool, error)
+	// Save stores the values of the session for ttl.
+	Save(ctx context.Context, id string, values map[string]any, ttl time.Duration) error
+	// Delete removes the session.
+	Delete(ctx context.Context, id string) error
+}
+
+// memoryEntry is a session stored in a MemoryStore.
+type memoryEntry struct {
+	values    map[string]any
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store keeping sessions in memory.
+// It is only suitable for a single instance or for tests, as Lambda instances do not share memory.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+	now      func() time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]memoryEntry),
+		now:      time.Now,
+	}
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(ctx context.Context, id string) (map[string]any, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.sessions[id]
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.sessions, id)
+		return nil, false, nil
+	}
+	return maps.Clone(entry.values), true, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(ctx context.Context, id string, values map[string]any, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	// Sweep expired sessions
+	for k, entry := range s.sessions {
+		if !now.Before(entry.expiresAt) {
+			delete(s.sessions, k)
+		}
+	}
+	s.sessions[id] = memoryEntry{values: maps.Clone(values), expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	_, ok, err := s.Load(ctx, "id-1")
+	assert.NoError(err)
+	assert.False(ok)
+
+	values := map[string]any{"user": "alice"}
+	assert.NoError(s.Save(ctx, "id-1", values, time.Hour))
+	values["user"] = "mallory"
+
+	loaded, ok, err := s.Load(ctx, "id-1")
+	assert.NoError(err)
+	assert.True(ok)
+	assert.Equal(map[string]any{"user": "alice"}, loaded)
+
+	// Expired
+	now = now.Add(time.Hour)
+	_, ok, err = s.Load(ctx, "id-1")
+	assert.NoError(err)
+	assert.False(ok)
+
+	assert.NoError(s.Save(ctx, "id-2", values, time.Hour))
+	assert.NoError(s.Delete(ctx, "id-2"))
+	_, ok, err = s.Load(ctx, "id-2")
+	assert.NoError(err)
+	assert.False(ok)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	assert.NoError(t, s.Save(ctx, "id-1", nil, time.Minute))
+	now = now.Add(time.Hour)
+	assert.NoError(t, s.Save(ctx, "id-2", nil, time.Minute))
+	assert.Len(t, s.sessions, 1)
+}
@@ -0,0 +1,92 @@
+package signature
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"time"
+)
+
+// Encoding is the text encoding of a signature.
+type Encoding int
+
+const (
+	// Hex is the hexadecimal encoding. Signatures are compared case-insensitively.
+	Hex Encoding = iota
+	// Base64 is the standard base64 encoding.
+	Base64
+)
+
+// Scheme describes how a webhook provider signs its requests.
+type Scheme struct {
+	// Header is the request header carrying the signature.
+	Header string
+	// Prefix is removed from the signature before decoding (e.g. "sha256=").
+	Prefix string
+	// Hash is the hash function of the HMAC.
+	Hash func() hash.Hash
+	// Encoding is the encoding of the signature.
+	Encoding Encoding
+
+	// SignatureKey, if set, means Header holds comma-separated key=value pairs (e.g. "t=123,v1=abc,v1=def"),
+	// and the signatures are the values of this key. Any of them may match.
+	SignatureKey string
+	// TimestampKey is the key of the Unix timestamp within Header, when SignatureKey is set.
+	TimestampKey string
+	// TimestampHeader is the request header carrying the Unix timestamp, when it is not part of Header.
+	TimestampHeader string
+	// Tolerance is the maximum difference between the timestamp and the current time.
+	// Requests outside this window are rejected as replays. Zero disables the check.
+	Tolerance time.Duration
+
+	// Payload is the template of the signed payload. "{timestamp}" and "{body}" are replaced by
+	// the timestamp and the raw request body. The default is "{body}".
+	Payload string
+}
+
+// GitHub returns the Scheme of GitHub webhooks (X-Hub-Signature-256 header).
+// GitHub does not sign a timestamp, so replays cannot be detected from the signature alone.
+func GitHub() Scheme {
+	return Scheme{
+		Header:   "X-Hub-Signature-256",
+		Prefix:   "sha256=",
+		Hash:     SHA256,
+		Encoding: Hex,
+		Payload:  "{body}",
+	}
+}
+
+// Stripe returns the Scheme of Stripe webhooks (Stripe-Signature header), with a tolerance of 5 minutes.
+func Stripe() Scheme {
+	return Scheme{
+		Header:       "Stripe-Signature",
+		Hash:         SHA256,
+		Encoding:     Hex,
+		SignatureKey: "v1",
+		TimestampKey: "t",
+		Tolerance:    5 * time.Minute,
+		Payload:      "{timestamp}.{body}",
+	}
+}
+
+// Slack returns the Scheme of Slack requests (X-Slack-Signature and X-Slack-Request-Timestamp headers),
+// with a tolerance of 5 minutes.
+func Slack() Scheme {
+	return Scheme{
+		Header:          "X-Slack-Signature",
+		Prefix:          "v0=",
+		Hash:            SHA256,
+		Encoding:        Hex,
+		TimestampHeader: "X-Slack-Request-Timestamp",
+		Tolerance:       5 * time.Minute,
+		Payload:         "v0:{timestamp}:{body}",
+	}
+}
+
+// SHA1, SHA256 and SHA512 are the hash functions commonly used by webhook providers, for use in Scheme.Hash.
+var (
+	SHA1   = sha1.New
+	SHA256 = sha256.New
+	SHA512 = sha512.New
+)
@@ -0,0 +1,237 @@
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware"
+	"github.com/nakat-t/aws-lambda-go-middleware/middleware/header"
+)
+
+const (
+	// defaultErrorBody is the default response body when the signature is invalid.
+	defaultErrorBody = "Unauthorized"
+
+	// defaultErrorContentType is the default Content-Type for error responses.
+	defaultErrorContentType = "text/plain; charset=utf-8"
+)
+
+var (
+	// errMissingSignature is returned when the request carries no signature.
+	errMissingSignature = errors.New("signature: missing signature")
+
+	// errMissingTimestamp is returned when the scheme requires a timestamp and the request carries none.
+	errMissingTimestamp = errors.New("signature: missing or invalid timestamp")
+
+	// errTimestamp is returned when the timestamp is outside the tolerance window.
+	errTimestamp = errors.New("signature: timestamp outside the tolerance window")
+
+	// errMismatch is returned when no signature matches the payload.
+	errMismatch = errors.New("signature: signature mismatch")
+)
+
+// Config is the configuration for the Verify middleware.
+type Config struct {
+	secrets          [][]byte
+	now              func() time.Time
+	errorBody        string
+	errorContentType string
+}
+
+// Option is a function type to modify the Verify configuration.
+type Option func(*Config)
+
+// WithSecrets adds secrets accepted besides the one given to Verify, e.g. while a secret is being rotated.
+// Empty secrets cause Verify to panic.
+func WithSecrets(secrets ...string) Option {
+	return func(c *Config) {
+		for _, secret := range secrets {
+			c.secrets = append(c.secrets, []byte(secret))
+		}
+	}
+}
+
+// WithClock sets the function returning the current time, used to check the timestamp.
+func WithClock(now func() time.Time) Option {
+	return func(c *Config) {
+		c.now = now
+	}
+}
+
+// WithResponse sets the response Content-Type header and response body returned when the signature is invalid.
+func WithResponse(contentType string, body string) Option {
+	return func(c *Config) {
+		c.errorContentType = contentType
+		c.errorBody = body
+	}
+}
+
+// Verify creates middleware that verifies the HMAC signature of webhook requests.
+//
+// The signature is computed over the raw request body (base64-decoded if IsBase64Encoded is set),
+// formatted with scheme.Payload, using secret. If the scheme signs a timestamp, requests whose timestamp
+// differs from the current time by more than scheme.Tolerance are rejected as replays.
+// Requests with a missing or invalid signature are rejected with 401 Unauthorized.
+// Verify panics if secret, or a secret given with WithSecrets, is empty: anyone could sign with an empty key.
+//
+// The request is passed to the next handler unchanged, so Verify must run before middleware that
+// decodes the body, such as validate.Validate.
+//
+// Example:
+//
+//	handler := middleware.Use(myHandler,
+//	    signature.Verify(signature.Stripe(), os.Getenv("STRIPE_WEBHOOK_SECRET")),
+//	    validate.Validate[Event](),
+//	)
+func Verify(scheme Scheme, secret string, opts ...Option) middleware.MiddlewareFunc {
+	// Default configuration
+	config := Config{
+		secrets:          [][]byte{[]byte(secret)},
+		now:              time.Now,
+		errorBody:        defaultErrorBody,
+		errorContentType: defaultErrorContentType,
+	}
+	// Apply options
+	for _, opt := range opts {
+		opt(&config)
+	}
+	for _, secret := range config.secrets {
+		if len(secret) == 0 {
+			panic(errors.New("signature: empty secret"))
+		}
+	}
+	if scheme.Hash == nil {
+		scheme.Hash = SHA256
+	}
+	if scheme.Payload == "" {
+		scheme.Payload = "{body}"
+	}
+
+	// Prepare error response
+	errorResponse := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusUnauthorized,
+		Body:       config.errorBody,
+		Headers:    map[string]string{"Content-Type": config.errorContentType},
+	}
+
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			if err := verify(&scheme, &config, &request); err != nil {
+				return errorResponse, nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// verify checks the signature of request.
+func verify(scheme *Scheme, config *Config, request *events.APIGatewayProxyRequest) error {
+	h := header.Request(request)
+	sigHeader := h.Get(scheme.Header)
+	if sigHeader == "" {
+		return errMissingSignature
+	}
+
+	// Extract the signatures and the timestamp
+	var signatures []string
+	var timestamp string
+	if scheme.SignatureKey != "" {
+		for _, pair := range strings.Split(sigHeader, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			switch key {
+			case scheme.SignatureKey:
+				signatures = append(signatures, value)
+			case scheme.TimestampKey:
+				timestamp = value
+			}
+		}
+	} else {
+		signatures = []string{sigHeader}
+	}
+	if scheme.TimestampHeader != "" {
+		timestamp = h.Get(scheme.TimestampHeader)
+	}
+	if len(signatures) == 0 {
+		return errMissingSignature
+	}
+
+	// Reject replays
+	signsTimestamp := scheme.TimestampKey != "" || scheme.TimestampHeader != ""
+	if signsTimestamp {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return errMissingTimestamp
+		}
+		if scheme.Tolerance > 0 {
+			diff := config.now().Sub(time.Unix(seconds, 0))
+			if diff > scheme.Tolerance || diff < -scheme.Tolerance {
+				return errTimestamp
+			}
+		}
+	}
+
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return err
+		}
+		body = decoded
+	}
+	payload := buildPayload(scheme.Payload, timestamp, body)
+
+	for _, secret := range config.secrets {
+		mac := hmac.New(scheme.Hash, secret)
+		mac.Write(payload)
+		expected := mac.Sum(nil)
+		for _, signature := range signatures {
+			given, err := decodeSignature(scheme, signature)
+			if err == nil && hmac.Equal(given, expected) {
+				return nil
+			}
+		}
+	}
+	return errMismatch
+}
+
+// buildPayload replaces "{timestamp}" and "{body}" in template.
+func buildPayload(template, timestamp string, body []byte) []byte {
+	payload := make([]byte, 0, len(template)+len(timestamp)+len(body))
+	for len(template) > 0 {
+		i := strings.IndexByte(template, '{')
+		if i < 0 {
+			break
+		}
+		payload = append(payload, template[:i]...)
+		template = template[i:]
+		switch {
+		case strings.HasPrefix(template, "{timestamp}"):
+			payload = append(payload, timestamp...)
+			template = template[len("{timestamp}"):]
+		case strings.HasPrefix(template, "{body}"):
+			payload = append(payload, body...)
+			template = template[len("{body}"):]
+		default:
+			payload = append(payload, '{')
+			template = template[1:]
+		}
+	}
+	return append(payload, template...)
+}
+
+// decodeSignature removes the scheme prefix from signature and decodes it.
+func decodeSignature(scheme *Scheme, signature string) ([]byte, error) {
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), scheme.Prefix)
+	if scheme.Encoding == Base64 {
+		return base64.StdEncoding.DecodeString(signature)
+	}
+	return hex.DecodeString(signature)
+}
@@ -0,0 +1,199 @@
+package signature
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// now is the fixed current time used in tests.
+var now = time.Unix(1531420618, 0)
+
+// sign returns the hex-encoded HMAC-SHA256 of payload.
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// run calls the Verify middleware with request and reports whether the next handler was called.
+func run(t *testing.T, scheme Scheme, secret string, request events.APIGatewayProxyRequest, opts ...Option) (events.APIGatewayProxyResponse, bool) {
+	nextCalled := false
+	opts = append([]Option{WithClock(func() time.Time { return now })}, opts...)
+	handler := Verify(scheme, secret, opts...)(func(ctx context.Context, r events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		nextCalled = true
+		assert.Equal(t, request.Body, r.Body)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+	response, err := handler(context.Background(), request)
+	assert.NoError(t, err)
+	return response, nextCalled
+}
+
+func TestVerify_GitHub(t *testing.T) {
+	secret := "It's a Secret to Everybody"
+	// Example from the GitHub documentation
+	signature := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+
+	tests := []struct {
+		name     string
+		request  events.APIGatewayProxyRequest
+		expected bool
+	}{
+		{
+			name:     "Valid signature",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"x-hub-signature-256": signature}, Body: "Hello, World!"},
+			expected: true,
+		},
+		{
+			name: "Base64 encoded body",
+			request: events.APIGatewayProxyRequest{
+				Headers:         map[string]string{"X-Hub-Signature-256": signature},
+				Body:            base64.StdEncoding.EncodeToString([]byte("Hello, World!")),
+				IsBase64Encoded: true,
+			},
+			expected: true,
+		},
+		{
+			name:     "Tampered body",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"X-Hub-Signature-256": signature}, Body: "Hello, World?"},
+			expected: false,
+		},
+		{
+			name:     "Missing prefix",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"X-Hub-Signature-256": signature[7:]}, Body: "Hello, World!"},
+			expected: true,
+		},
+		{
+			name:     "Invalid hex",
+			request:  events.APIGatewayProxyRequest{Headers: map[string]string{"X-Hub-Signature-256": "sha256=zz"}, Body: "Hello, World!"},
+			expected: false,
+		},
+		{
+			name:     "Missing signature",
+			request:  events.APIGatewayProxyRequest{Body: "Hello, World!"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, nextCalled := run(t, GitHub(), secret, tt.request)
+			assert.Equal(t, tt.expected, nextCalled)
+			if !tt.expected {
+				assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+				assert.Equal(t, defaultErrorBody, response.Body)
+				assert.Equal(t, defaultErrorContentType, response.Headers["Content-Type"])
+			}
+		})
+	}
+}
+
+func TestVerify_Stripe(t *testing.T) {
+	secret := "whsec_test"
+	body := `{"id":"evt_1","type":"charge.succeeded"}`
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	old := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected bool
+	}{
+		{"Valid signature", "t=" + timestamp + ",v1=" + sign(secret, timestamp+"."+body), true},
+		{"One of several signatures", "t=" + timestamp + ",v1=" + sign("other", timestamp+"."+body) + ",v1=" + sign(secret, timestamp+"."+body) + ",v0=abc", true},
+		{"Replay outside tolerance", "t=" + old + ",v1=" + sign(secret, old+"."+body), false},
+		{"Timestamp not signed", "t=" + timestamp + ",v1=" + sign(secret, body), false},
+		{"Missing timestamp", "v1=" + sign(secret, timestamp+"."+body), false},
+		{"Missing v1 signature", "t=" + timestamp + ",v0=" + sign(secret, timestamp+"."+body), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayProxyRequest{Headers: map[string]string{"Stripe-Signature": tt.header}, Body: body}
+			_, nextCalled := run(t, Stripe(), secret, request)
+			assert.Equal(t, tt.expected, nextCalled)
+		})
+	}
+}
+
+func TestVerify_Slack(t *testing.T) {
+	assert := assert.New(t)
+	// Example from the Slack documentation
+	secret := "8f742231b10e8888abcd99yyyzzz85a5"
+	body := "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+	headers := map[string]string{
+		"X-Slack-Signature":         "v0=a2114d57b48eac39b9ad189dd8316235a7b4a8d21a10bd27519666489c69b503",
+		"X-Slack-Request-Timestamp": "1531420618",
+	}
+
+	_, nextCalled := run(t, Slack(), secret, events.APIGatewayProxyRequest{Headers: headers, Body: body})
+	assert.True(nextCalled)
+
+	// The same request six minutes later is a replay
+	later := now
+	now = now.Add(6 * time.Minute)
+	defer func() { now = later }()
+	_, nextCalled = run(t, Slack(), secret, events.APIGatewayProxyRequest{Headers: headers, Body: body})
+	assert.False(nextCalled)
+}
+
+func TestVerify_Generic(t *testing.T) {
+	assert := assert.New(t)
+	body := `{"order":1}`
+	mac := hmac.New(SHA512, []byte("new-secret"))
+	mac.Write([]byte("1531420618|" + body))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	scheme := Scheme{
+		Header:          "X-Signature",
+		Hash:            SHA512,
+		Encoding:        Base64,
+		TimestampHeader: "X-Timestamp",
+		Tolerance:       time.Minute,
+		Payload:         "{timestamp}|{body}",
+	}
+	request := events.APIGatewayProxyRequest{
+		MultiValueHeaders: map[string][]string{"X-Signature": {signature}, "X-Timestamp": {"1531420618"}},
+		Body:              body,
+	}
+
+	// The secret is rotated: both the old and the new secrets are accepted
+	_, nextCalled := run(t, scheme, "old-secret", request, WithSecrets("new-secret"))
+	assert.True(nextCalled)
+
+	response, nextCalled := run(t, scheme, "old-secret", request, WithResponse("application/json", `{"error":"invalid signature"}`))
+	assert.False(nextCalled)
+	assert.Equal(http.StatusUnauthorized, response.StatusCode)
+	assert.Equal(`{"error":"invalid signature"}`, response.Body)
+	assert.Equal("application/json", response.Headers["Content-Type"])
+}
+
+func TestVerify_EmptySecret(t *testing.T) {
+	assert := assert.New(t)
+	request := events.APIGatewayProxyRequest{
+		Headers: map[string]string{"X-Hub-Signature-256": "sha256=" + sign("", "Hello, World!")},
+		Body:    "Hello, World!",
+	}
+
+	// A request signed with the empty key must never be accepted: the configuration is rejected
+	assert.Panics(func() { run(t, GitHub(), "", request) })
+	assert.Panics(func() { run(t, GitHub(), "secret", request, WithSecrets("")) })
+
+	_, nextCalled := run(t, GitHub(), "secret", request)
+	assert.False(nextCalled)
+}
+
+func TestBuildPayload(t *testing.T) {
+	assert.Equal(t, "v0:123:{body} {x}", string(buildPayload("v0:{timestamp}:{body} {x}", "123", []byte("{body}"))))
+	assert.Equal(t, "abc", string(buildPayload("{body}", "", []byte("abc"))))
+}